@@ -2,49 +2,348 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/ankardo/Rate-Limiter/config"
 	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/app/access"
+	"github.com/ankardo/Rate-Limiter/internal/app/apikey"
+	"github.com/ankardo/Rate-Limiter/internal/app/auth"
 	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
 	"github.com/ankardo/Rate-Limiter/internal/app/middleware"
 	"github.com/ankardo/Rate-Limiter/internal/domain"
 	"github.com/ankardo/Rate-Limiter/internal/infrastructure/persistence"
 	"github.com/ankardo/Rate-Limiter/internal/infrastructure/webserver"
+	"go.uber.org/zap"
 )
 
 func main() {
 	cfg := config.LoadConfig("./.env")
 	ctx := context.Background()
 	var rateLimiter domain.Limiter
+	var quota domain.QuotaLimiter
+	var concurrencyLimiter domain.ConcurrencyLimiter
+	var accessList *access.AccessList
+	var apiKeyStore domain.APIKeyStore
+	var keyLimiter, keyFallback middleware.KeyLimiterFunc
+	useAccessList := len(cfg.Allowlist) > 0 || len(cfg.Denylist) > 0
+
+	limiterConfig := domain.LimiterConfig{
+		MaxRequests:        cfg.MaxRequests,
+		TokenMaxRequests:   cfg.TokenMaxRequests,
+		BlockDuration:      int64(cfg.BlockDuration),
+		Window:             cfg.Window,
+		TokenWindow:        cfg.TokenWindow,
+		Limits:             cfg.Limits,
+		TokenLimits:        cfg.TokenLimits,
+		BurstCapacity:      cfg.BurstCapacity,
+		TokenBurstCapacity: cfg.TokenBurstCapacity,
+		CleanupInterval:    int64(cfg.CleanupInterval),
+		MaxKeys:            cfg.MaxKeys,
+		MaxConcurrent:      cfg.MaxConcurrent,
+		TokenMaxConcurrent: cfg.TokenMaxConcurrent,
+		LeaseDuration:      time.Duration(cfg.LeaseDuration) * time.Second,
+	}
+	limitConcurrency := cfg.MaxConcurrent > 0 || cfg.TokenMaxConcurrent > 0
+	policies := make([]middleware.RoutePolicy, len(cfg.RoutePolicies))
+	for i, policy := range cfg.RoutePolicies {
+		policies[i] = middleware.RoutePolicy{RoutePolicy: policy}
+	}
+	tiers := make([]middleware.LimitTier, len(cfg.LimitTiers))
+	for i, tier := range cfg.LimitTiers {
+		tiers[i] = middleware.LimitTier{LimitTier: tier}
+	}
+	stackedConfig := func(limits []domain.LimitRule) domain.LimiterConfig {
+		config := limiterConfig
+		config.Limits = limits
+		config.TokenLimits = limits
+		return config
+	}
+
+	strategy := cfg.Strategy
+	if len(cfg.Limits) > 0 || len(cfg.TokenLimits) > 0 {
+		strategy = domain.StrategyStacked
+	}
+
+	failure := middleware.FailurePolicy{Mode: cfg.FailureMode}
 
 	if os.Getenv("USE_MEMORY_STORE") == "true" {
-		rateLimiter = limiter.NewMemoryRateLimiter(domain.LimiterConfig{
-			MaxRequests:      cfg.MaxRequests,
-			TokenMaxRequests: cfg.TokenMaxRequests,
-			BlockDuration:    int64(cfg.BlockDuration),
-		})
-		logger.Info("Using in-memory rate limiter")
+		rateLimiter = newMemoryLimiter(strategy, limiterConfig)
+		if len(cfg.QuotaRules) > 0 {
+			logger.Info("Quotas require the Redis store and are disabled")
+		}
+		if limitConcurrency {
+			concurrencyLimiter = limiter.NewMemoryConcurrencyLimiter(limiterConfig)
+		}
+		for i, policy := range policies {
+			if len(policy.Limits) > 0 {
+				policies[i].Limiter = limiter.NewMemoryStackedLimiter(stackedConfig(policy.Limits))
+			}
+		}
+		for i, tier := range tiers {
+			tiers[i].Limiter = limiter.NewMemoryStackedLimiter(stackedConfig(tier.Limits))
+		}
+		keyLimiter = func(limits []domain.LimitRule) domain.Limiter {
+			return limiter.NewMemoryStackedLimiter(stackedConfig(limits))
+		}
+		if useAccessList {
+			accessList = access.NewAccessList(cfg.Allowlist, cfg.Denylist)
+		}
+		logger.Info("Using in-memory rate limiter", zap.String("strategy", strategy))
 	} else {
 		redisClient, err := persistence.NewRedisClient(ctx, cfg.RedisAddr, cfg.RedisPassword)
 		if err != nil {
 			logger.Error("Failed to connect to Redis: %v", err)
 		}
-		redisStore := persistence.NewRedisStore(redisClient)
-		rateLimiter = limiter.NewRedisRateLimiter(redisStore, domain.LimiterConfig{
-			MaxRequests:      cfg.MaxRequests,
-			TokenMaxRequests: cfg.TokenMaxRequests,
-			BlockDuration:    int64(cfg.BlockDuration),
-			TTLExpiration:    int64(cfg.TTLExpiration),
-		})
-		logger.Info("Using Redis rate limiter")
+		storeConfig := persistence.RedisStoreConfig{Timeout: cfg.RedisTimeout, AdminTimeout: cfg.RedisAdminTimeout}
+		if cfg.BreakerThreshold > 0 {
+			breaker := persistence.NewCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown)
+			expvar.Publish("redis_circuit_breaker_state", expvar.Func(func() any { return breaker.State() }))
+			storeConfig.Breaker = breaker
+		}
+		redisStore := persistence.NewRedisStoreWithConfig(redisClient, storeConfig)
+		switch strategy {
+		case domain.StrategyStacked:
+			rateLimiter = limiter.NewRedisStackedLimiter(redisStore, limiterConfig)
+		case domain.StrategyTokenBucket:
+			rateLimiter = limiter.NewRedisTokenBucketLimiter(redisStore, limiterConfig)
+		case domain.StrategyGCRA:
+			rateLimiter = limiter.NewRedisGCRALimiter(redisStore, limiterConfig)
+		case domain.StrategySlidingLog:
+			rateLimiter = limiter.NewRedisSlidingLogLimiter(redisStore, limiterConfig)
+		case domain.StrategySlidingWindow:
+			rateLimiter = limiter.NewRedisSlidingWindowLimiter(redisStore, limiterConfig)
+		default:
+			rateLimiter = limiter.NewRedisRateLimiter(redisStore, limiterConfig)
+		}
+		if len(cfg.QuotaRules) > 0 {
+			quota = limiter.NewRedisQuotaLimiter(redisStore, cfg.QuotaRules, cfg.QuotaLocation)
+			logger.Info("Using Redis quotas", zap.String("timezone", cfg.QuotaLocation.String()))
+		}
+		if limitConcurrency {
+			concurrencyLimiter = limiter.NewRedisConcurrencyLimiter(redisStore, limiterConfig)
+		}
+		for i, policy := range policies {
+			if len(policy.Limits) > 0 {
+				policies[i].Limiter = limiter.NewRedisStackedLimiter(redisStore, stackedConfig(policy.Limits))
+			}
+		}
+		for i, tier := range tiers {
+			tiers[i].Limiter = limiter.NewRedisStackedLimiter(redisStore, stackedConfig(tier.Limits))
+		}
+		keyLimiter = func(limits []domain.LimitRule) domain.Limiter {
+			return limiter.NewRedisStackedLimiter(redisStore, stackedConfig(limits))
+		}
+		accessList = access.NewStoreAccessList(redisStore, cfg.Allowlist, cfg.Denylist, time.Duration(cfg.AccessListRefresh)*time.Second)
+		useAccessList = true
+		apiKeyStore = redisStore
+
+		fallbackLimiter := func(limits []domain.LimitRule) domain.Limiter {
+			return limiter.NewMemoryStackedLimiter(limiter.ScaleConfig(stackedConfig(limits), cfg.FallbackScale))
+		}
+		failure.Fallback = newMemoryLimiter(strategy, limiter.ScaleConfig(limiterConfig, cfg.FallbackScale))
+		for i, policy := range policies {
+			if len(policy.Limits) > 0 {
+				policies[i].Fallback = fallbackLimiter(policy.Limits)
+			}
+		}
+		for i, tier := range tiers {
+			tiers[i].Fallback = fallbackLimiter(tier.Limits)
+		}
+		keyFallback = fallbackLimiter
+		logger.Info("Handling store failures",
+			zap.String("failureMode", cfg.FailureMode),
+			zap.Float64("fallbackScale", cfg.FallbackScale),
+		)
+		logger.Info("Using Redis rate limiter", zap.String("strategy", strategy))
+	}
+
+	closers := limiter.NewClosers()
+	closers.Track(rateLimiter, failure.Fallback, concurrencyLimiter)
+	for _, policy := range policies {
+		closers.Track(policy.Limiter, policy.Fallback)
+	}
+	for _, tier := range tiers {
+		closers.Track(tier.Limiter, tier.Fallback)
 	}
 
-	rateLimiterMiddleware := middleware.RateLimiterMiddleware(rateLimiter)
-	mux := webserver.NewRouter(rateLimiterMiddleware)
+	publishMemoryStats("memory_limiter_stats", rateLimiter)
+	publishMemoryStats("memory_fallback_limiter_stats", failure.Fallback)
 
-	logger.Info("Server is running on port 8080")
-	log.Fatal(http.ListenAndServe(":8080", mux))
+	var cost middleware.CostFunc
+	if len(cfg.RouteCosts) > 0 {
+		routeCosts, err := middleware.ParseRouteCosts(cfg.RouteCosts)
+		if err != nil {
+			logger.Error("Invalid route costs, charging 1 per request", err)
+		} else {
+			cost = routeCosts
+			logger.Info("Using route costs", zap.Any("routes", cfg.RouteCosts))
+		}
+	}
+
+	clientIP := middleware.NewClientIPResolver(middleware.ClientIPConfig{
+		TrustedProxies: cfg.TrustedProxies,
+		Headers:        cfg.ClientIPHeaders,
+		IPv4Prefix:     cfg.IPv4KeyPrefix,
+		IPv6Prefix:     cfg.IPv6KeyPrefix,
+	})
+	if len(cfg.TrustedProxies) > 0 {
+		logger.Info("Resolving client IPs behind trusted proxies", zap.Int("trustedProxies", len(cfg.TrustedProxies)))
+	}
+
+	keys := middleware.DefaultKeyExtractor(clientIP)
+	registeredKeys := false
+	if cfg.RateLimitKeys != "" {
+		configuredKeys, err := middleware.ParseKeyExtractor(cfg.RateLimitKeys, clientIP)
+		if err != nil {
+			logger.Error("Invalid rate limit keys, using the default keys", err)
+		} else {
+			keys = configuredKeys
+			logger.Info("Using rate limit keys", zap.String("keys", cfg.RateLimitKeys))
+		}
+	}
+	if cfg.JWTSecretFile != "" || cfg.JWTPublicKeyFile != "" || cfg.JWTJWKSFile != "" {
+		jwtKeys, err := auth.LoadKeyFiles(cfg.JWTSecretFile, cfg.JWTPublicKeyFile, cfg.JWTJWKSFile)
+		if err != nil {
+			logger.Error("Failed to load JWT keys, bearer tokens are ignored", err)
+		} else {
+			verifier := auth.NewVerifier(auth.VerifierConfig{
+				Keys:     jwtKeys,
+				Issuer:   cfg.JWTIssuer,
+				Audience: cfg.JWTAudience,
+				Leeway:   time.Minute,
+			})
+			keys = middleware.FirstKey(middleware.JWTClaimKey(middleware.JWTKeyConfig{
+				Verifier:  verifier,
+				KeyClaims: cfg.JWTKeyClaims,
+				TierClaim: cfg.JWTTierClaim,
+			}), keys)
+			logger.Info("Keying requests on JWT claims",
+				zap.Int("keys", len(jwtKeys)),
+				zap.Strings("claims", cfg.JWTKeyClaims),
+				zap.String("tierClaim", cfg.JWTTierClaim),
+				zap.Int("tiers", len(tiers)),
+			)
+		}
+	}
+
+	if cfg.APIKeysFile != "" || cfg.UnknownAPIKeys != "" {
+		var loadedKeys []domain.APIKey
+		if cfg.APIKeysFile != "" {
+			fileKeys, err := apikey.LoadFile(cfg.APIKeysFile)
+			if err != nil {
+				logger.Error("Failed to load API keys", err, zap.String("file", cfg.APIKeysFile))
+			}
+			loadedKeys = fileKeys
+		}
+		unknown := cfg.UnknownAPIKeys
+		switch unknown {
+		case "":
+			unknown = middleware.UnknownAPIKeyIP
+		case middleware.UnknownAPIKeyAllow, middleware.UnknownAPIKeyReject, middleware.UnknownAPIKeyIP:
+		default:
+			logger.Info("Invalid UNKNOWN_API_KEYS, rejecting unknown API keys", zap.String("value", unknown))
+			unknown = middleware.UnknownAPIKeyReject
+		}
+		registry := apikey.NewRegistryWithConfig(loadedKeys, apiKeyStore, apikey.RegistryConfig{CacheTTL: cfg.APIKeyCacheTTL})
+		keys = middleware.RegisteredKey(registry, unknown, middleware.ClientIPKey(clientIP), keys)
+		registeredKeys = true
+		logger.Info("Resolving API keys from the registry",
+			zap.Int("keys", len(loadedKeys)),
+			zap.Bool("redis", apiKeyStore != nil),
+			zap.Duration("cacheTTL", cfg.APIKeyCacheTTL),
+			zap.String("unknown", unknown),
+		)
+	}
+
+	rateLimiterMiddleware := middleware.RateLimiterMiddlewareWithConfig(rateLimiter, middleware.MiddlewareConfig{
+		HeaderStyle: cfg.HeaderStyle,
+		Quota:       quota,
+		Cost:        cost,
+		Policies:    policies,
+		ClientIP:    clientIP,
+		Keys:        keys,
+		Tiers:       tiers,
+		KeyLimiter:  keyLimiter,
+		KeyFallback: keyFallback,
+		Failure:     failure,
+		Closers:     closers,
+	})
+	if concurrencyLimiter != nil {
+		concurrencyMiddleware := middleware.ExemptRoutes(policies, middleware.ConcurrencyLimiterMiddleware(concurrencyLimiter, keys, cfg.FailureMode))
+		limitRate := rateLimiterMiddleware
+		rateLimiterMiddleware = func(next http.Handler) http.Handler {
+			return limitRate(concurrencyMiddleware(next))
+		}
+		logger.Info("Limiting concurrent requests",
+			zap.Int("maxConcurrent", cfg.MaxConcurrent),
+			zap.Int("tokenMaxConcurrent", cfg.TokenMaxConcurrent),
+		)
+	}
+	if useAccessList {
+		rateLimiterMiddleware = middleware.AccessListMiddleware(accessList, clientIP, rateLimiterMiddleware)
+	}
+	blockManager, _ := rateLimiter.(domain.BlockManager)
+	mux := webserver.NewRouter(rateLimiterMiddleware, webserver.RouterConfig{
+		BlockManager:   blockManager,
+		Quota:          quota,
+		Keys:           keys,
+		RegisteredKeys: registeredKeys,
+		AdminToken:     cfg.AdminToken,
+	})
+
+	server := &http.Server{Addr: ":8080", Handler: mux}
+	go func() {
+		logger.Info("Server is running on port 8080")
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	shutdownCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-shutdownCtx.Done()
+
+	logger.Info("Shutting down server")
+	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(timeoutCtx); err != nil {
+		logger.Error("Server shutdown failed", err)
+	}
+	if accessList != nil {
+		accessList.Close()
+	}
+	if err := closers.Close(); err != nil {
+		logger.Error("Rate limiter close failed", err)
+	}
+}
+
+// publishMemoryStats serves the key, block and eviction counters of an
+// in-memory limiter on /admin/metrics.
+func publishMemoryStats(name string, rateLimiter domain.Limiter) {
+	if reporter, ok := rateLimiter.(interface{ Stats() limiter.MemoryStats }); ok {
+		expvar.Publish(name, expvar.Func(func() any { return reporter.Stats() }))
+	}
+}
+
+func newMemoryLimiter(strategy string, config domain.LimiterConfig) domain.Limiter {
+	switch strategy {
+	case domain.StrategyStacked:
+		return limiter.NewMemoryStackedLimiter(config)
+	case domain.StrategyTokenBucket:
+		return limiter.NewMemoryTokenBucketLimiter(config)
+	case domain.StrategyGCRA:
+		return limiter.NewMemoryGCRALimiter(config)
+	case domain.StrategySlidingWindow:
+		return limiter.NewMemorySlidingWindowLimiter(config)
+	default:
+		return limiter.NewMemoryRateLimiter(config)
+	}
 }
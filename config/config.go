@@ -1,20 +1,80 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"net/netip"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ankardo/Rate-Limiter/internal/domain"
 	"github.com/joho/godotenv"
 )
 
+var windowUnits = map[string]time.Duration{
+	"s":      time.Second,
+	"sec":    time.Second,
+	"second": time.Second,
+	"m":      time.Minute,
+	"min":    time.Minute,
+	"minute": time.Minute,
+	"h":      time.Hour,
+	"hour":   time.Hour,
+	"d":      24 * time.Hour,
+	"day":    24 * time.Hour,
+}
+
 type Config struct {
-	RedisAddr        string
-	RedisPassword    string
-	MaxRequests      int
-	TokenMaxRequests int
-	BlockDuration    int
-	TTLExpiration    int
+	RedisAddr          string
+	RedisPassword      string
+	MaxRequests        int
+	TokenMaxRequests   int
+	BlockDuration      int
+	Window             time.Duration
+	TokenWindow        time.Duration
+	Limits             []domain.LimitRule
+	TokenLimits        []domain.LimitRule
+	QuotaRules         []domain.QuotaRule
+	QuotaLocation      *time.Location
+	Strategy           string
+	BurstCapacity      int
+	TokenBurstCapacity int
+	HeaderStyle        string
+	AdminToken         string
+	CleanupInterval    int
+	MaxKeys            int
+	RouteCosts         map[string]string
+	MaxConcurrent      int
+	TokenMaxConcurrent int
+	LeaseDuration      int
+	RoutePolicies      []domain.RoutePolicy
+	TrustedProxies     []netip.Prefix
+	ClientIPHeaders    []string
+	IPv4KeyPrefix      int
+	IPv6KeyPrefix      int
+	Allowlist          []netip.Prefix
+	Denylist           []netip.Prefix
+	AccessListRefresh  int
+	RateLimitKeys      string
+	JWTSecretFile      string
+	JWTPublicKeyFile   string
+	JWTJWKSFile        string
+	JWTIssuer          string
+	JWTAudience        string
+	JWTKeyClaims       []string
+	JWTTierClaim       string
+	LimitTiers         []domain.LimitTier
+	APIKeysFile        string
+	UnknownAPIKeys     string
+	APIKeyCacheTTL     time.Duration
+	FailureMode        string
+	FallbackScale      float64
+	RedisTimeout       time.Duration
+	RedisAdminTimeout  time.Duration
+	BreakerThreshold   int
+	BreakerCooldown    time.Duration
 }
 
 func LoadConfig(envPath string) Config {
@@ -26,16 +86,356 @@ func LoadConfig(envPath string) Config {
 	maxRequests, _ := strconv.Atoi(getEnv("MAX_REQUESTS_PER_SECOND", "5"))
 	tokenMaxRequests, _ := strconv.Atoi(getEnv("TOKEN_MAX_REQUESTS", "10"))
 	blockDuration, _ := strconv.Atoi(getEnv("BLOCK_DURATION_SECONDS", "60"))
-	ttlExpiration, _ := strconv.Atoi(getEnv("TTL_EXPIRATION_SECONDS", "60"))
+	windowDefault := legacyWindow()
+	window := parseWindowEnv("RATE_LIMIT_WINDOW", windowDefault)
+	tokenWindow := parseWindowEnv("TOKEN_RATE_LIMIT_WINDOW", getEnv("RATE_LIMIT_WINDOW", windowDefault))
+	burstCapacity, _ := strconv.Atoi(getEnv("BURST_CAPACITY", "0"))
+	tokenBurstCapacity, _ := strconv.Atoi(getEnv("TOKEN_BURST_CAPACITY", "0"))
+	limits := parseLimitsEnv("RATE_LIMITS")
+	tokenLimits := parseLimitsEnv("TOKEN_RATE_LIMITS")
+	dailyQuota, _ := strconv.ParseInt(getEnv("QUOTA_DAILY", "0"), 10, 64)
+	monthlyQuota, _ := strconv.ParseInt(getEnv("QUOTA_MONTHLY", "0"), 10, 64)
+	var quotaRules []domain.QuotaRule
+	if dailyQuota > 0 {
+		quotaRules = append(quotaRules, domain.QuotaRule{Period: domain.QuotaDaily, Limit: dailyQuota})
+	}
+	if monthlyQuota > 0 {
+		quotaRules = append(quotaRules, domain.QuotaRule{Period: domain.QuotaMonthly, Limit: monthlyQuota})
+	}
+	quotaLocation, err := time.LoadLocation(getEnv("QUOTA_TIMEZONE", "UTC"))
+	if err != nil {
+		log.Println("Invalid QUOTA_TIMEZONE, using UTC:", err)
+		quotaLocation = time.UTC
+	}
+	cleanupInterval, _ := strconv.Atoi(getEnv("MEMORY_CLEANUP_INTERVAL_SECONDS", "60"))
+	maxKeys, _ := strconv.Atoi(getEnv("MEMORY_MAX_KEYS", "100000"))
+	maxConcurrent, _ := strconv.Atoi(getEnv("MAX_CONCURRENT_REQUESTS", "0"))
+	tokenMaxConcurrent, _ := strconv.Atoi(getEnv("TOKEN_MAX_CONCURRENT_REQUESTS", "0"))
+	leaseDuration, _ := strconv.Atoi(getEnv("CONCURRENCY_LEASE_SECONDS", "60"))
+	routePolicies, err := ParseRoutePolicies(getEnv("ROUTE_POLICIES", ""))
+	if err != nil {
+		log.Println("Invalid ROUTE_POLICIES, ignoring it:", err)
+	}
+	trustedProxies, err := ParseNetworks(getEnv("TRUSTED_PROXIES", ""))
+	if err != nil {
+		log.Println("Invalid TRUSTED_PROXIES, ignoring it:", err)
+	}
+	allowlist, err := ParseNetworks(getEnv("ALLOWLIST", ""))
+	if err != nil {
+		log.Println("Invalid ALLOWLIST, ignoring it:", err)
+	}
+	denylist, err := ParseNetworks(getEnv("DENYLIST", ""))
+	if err != nil {
+		log.Println("Invalid DENYLIST, ignoring it:", err)
+	}
+	accessListRefresh, _ := strconv.Atoi(getEnv("ACCESS_LIST_REFRESH_SECONDS", "10"))
+	ipv4KeyPrefix, _ := strconv.Atoi(getEnv("IPV4_KEY_PREFIX", "32"))
+	ipv6KeyPrefix, _ := strconv.Atoi(getEnv("IPV6_KEY_PREFIX", "64"))
+	failureMode := strings.ToLower(strings.TrimSpace(getEnv("FAILURE_MODE", domain.FailureModeClosed)))
+	if !domain.ValidFailureMode(failureMode) {
+		log.Println("Invalid FAILURE_MODE, failing closed:", failureMode)
+		failureMode = domain.FailureModeClosed
+	}
+	fallbackScale, err := strconv.ParseFloat(getEnv("FALLBACK_LIMIT_SCALE", "0.5"), 64)
+	if err != nil || fallbackScale <= 0 || fallbackScale > 1 {
+		log.Println("Invalid FALLBACK_LIMIT_SCALE, using 0.5")
+		fallbackScale = 0.5
+	}
+	redisTimeout, _ := strconv.Atoi(getEnv("REDIS_TIMEOUT_MS", "100"))
+	apiKeyCacheSeconds, _ := strconv.Atoi(getEnv("API_KEY_CACHE_SECONDS", "30"))
+	redisAdminTimeout, _ := strconv.Atoi(getEnv("REDIS_ADMIN_TIMEOUT_MS", "5000"))
+	breakerThreshold, _ := strconv.Atoi(getEnv("CIRCUIT_BREAKER_THRESHOLD", "5"))
+	breakerCooldown, _ := strconv.Atoi(getEnv("CIRCUIT_BREAKER_COOLDOWN_SECONDS", "5"))
+	limitTiers, err := ParseLimitTiers(getEnv("LIMIT_TIERS", ""))
+	if err != nil {
+		log.Println("Invalid LIMIT_TIERS, ignoring it:", err)
+	}
+	routeCosts, err := ParseRouteCosts(getEnv("ROUTE_COSTS", ""))
+	if err != nil {
+		log.Println("Invalid ROUTE_COSTS, ignoring it:", err)
+	}
 
 	return Config{
-		RedisAddr:        getEnv("REDIS_ADDR", "localhost:6379"),
-		RedisPassword:    getEnv("REDIS_PASSWORD", ""),
-		MaxRequests:      maxRequests,
-		TokenMaxRequests: tokenMaxRequests,
-		BlockDuration:    blockDuration,
-		TTLExpiration:    ttlExpiration,
+		RedisAddr:          getEnv("REDIS_ADDR", "localhost:6379"),
+		RedisPassword:      getEnv("REDIS_PASSWORD", ""),
+		MaxRequests:        maxRequests,
+		TokenMaxRequests:   tokenMaxRequests,
+		BlockDuration:      blockDuration,
+		Window:             window,
+		TokenWindow:        tokenWindow,
+		Limits:             limits,
+		TokenLimits:        tokenLimits,
+		QuotaRules:         quotaRules,
+		QuotaLocation:      quotaLocation,
+		Strategy:           getEnv("LIMITER_STRATEGY", "window"),
+		BurstCapacity:      burstCapacity,
+		TokenBurstCapacity: tokenBurstCapacity,
+		HeaderStyle:        getEnv("RATE_LIMIT_HEADERS", "both"),
+		AdminToken:         getEnv("ADMIN_TOKEN", ""),
+		CleanupInterval:    cleanupInterval,
+		MaxKeys:            maxKeys,
+		RouteCosts:         routeCosts,
+		MaxConcurrent:      maxConcurrent,
+		TokenMaxConcurrent: tokenMaxConcurrent,
+		LeaseDuration:      leaseDuration,
+		RoutePolicies:      routePolicies,
+		TrustedProxies:     trustedProxies,
+		ClientIPHeaders:    parseList(getEnv("CLIENT_IP_HEADERS", "")),
+		IPv4KeyPrefix:      ipv4KeyPrefix,
+		IPv6KeyPrefix:      ipv6KeyPrefix,
+		Allowlist:          allowlist,
+		Denylist:           denylist,
+		AccessListRefresh:  accessListRefresh,
+		RateLimitKeys:      strings.TrimSpace(getEnv("RATE_LIMIT_KEYS", "")),
+		JWTSecretFile:      getEnv("JWT_HMAC_SECRET_FILE", ""),
+		JWTPublicKeyFile:   getEnv("JWT_PUBLIC_KEY_FILE", ""),
+		JWTJWKSFile:        getEnv("JWT_JWKS_FILE", ""),
+		JWTIssuer:          getEnv("JWT_ISSUER", ""),
+		JWTAudience:        getEnv("JWT_AUDIENCE", ""),
+		JWTKeyClaims:       parseList(getEnv("JWT_KEY_CLAIMS", "sub")),
+		JWTTierClaim:       getEnv("JWT_TIER_CLAIM", "plan"),
+		LimitTiers:         limitTiers,
+		APIKeysFile:        getEnv("API_KEYS_FILE", ""),
+		UnknownAPIKeys:     strings.ToLower(strings.TrimSpace(getEnv("UNKNOWN_API_KEYS", ""))),
+		APIKeyCacheTTL:     time.Duration(apiKeyCacheSeconds) * time.Second,
+		FailureMode:        failureMode,
+		FallbackScale:      fallbackScale,
+		RedisTimeout:       time.Duration(redisTimeout) * time.Millisecond,
+		RedisAdminTimeout:  time.Duration(redisAdminTimeout) * time.Millisecond,
+		BreakerThreshold:   breakerThreshold,
+		BreakerCooldown:    time.Duration(breakerCooldown) * time.Second,
+	}
+}
+
+func ParseWindow(value string) (time.Duration, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if unit, ok := windowUnits[value]; ok {
+		return unit, nil
+	}
+
+	var window time.Duration
+	var err error
+	if days, found := strings.CutSuffix(value, "d"); found {
+		var count float64
+		count, err = strconv.ParseFloat(days, 64)
+		window = time.Duration(count * float64(24*time.Hour))
+	} else {
+		window, err = time.ParseDuration(value)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid window %q: %w", value, err)
+	}
+	if window <= 0 {
+		return 0, fmt.Errorf("invalid window %q: must be positive", value)
+	}
+	return window, nil
+}
+
+func ParseLimits(value string) ([]domain.LimitRule, error) {
+	var rules []domain.LimitRule
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		count, window, found := strings.Cut(item, "/")
+		if !found {
+			return nil, fmt.Errorf("invalid limit %q: expected <requests>/<window>", item)
+		}
+		maxRequests, err := parseCount(count)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit %q: %w", item, err)
+		}
+		duration, err := ParseWindow(window)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit %q: %w", item, err)
+		}
+		rules = append(rules, domain.LimitRule{MaxRequests: maxRequests, Window: duration})
+	}
+	return rules, nil
+}
+
+func ParseRouteCosts(value string) (map[string]string, error) {
+	costs := make(map[string]string)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		route, cost, found := strings.Cut(item, "=")
+		route, cost = strings.TrimSpace(route), strings.TrimSpace(cost)
+		if !found || route == "" || cost == "" {
+			return nil, fmt.Errorf("invalid route cost %q: expected [METHOD ]<path>=<cost>", item)
+		}
+		if method, path, found := strings.Cut(route, " "); found {
+			route = strings.ToUpper(method) + " " + strings.TrimSpace(path)
+		}
+		costs[route] = cost
+	}
+	return costs, nil
+}
+
+func ParseRoutePolicies(value string) ([]domain.RoutePolicy, error) {
+	var policies []domain.RoutePolicy
+	for _, item := range strings.Split(value, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		route, limits, found := strings.Cut(item, "=")
+		route, limits = strings.TrimSpace(route), strings.TrimSpace(limits)
+		if !found || route == "" || limits == "" {
+			return nil, fmt.Errorf("invalid route policy %q: expected [METHOD ]<pattern>=<limits>[,failure:<mode>]|exempt", item)
+		}
+
+		policy := domain.RoutePolicy{Pattern: route}
+		if method, pattern, found := strings.Cut(route, " "); found {
+			policy.Method = strings.ToUpper(method)
+			policy.Pattern = strings.TrimSpace(pattern)
+		}
+		if !strings.HasPrefix(policy.Pattern, "/") {
+			return nil, fmt.Errorf("invalid route policy %q: pattern must start with /", item)
+		}
+		policy.Name = strings.ToLower(policy.Method) + policy.Pattern
+
+		if strings.EqualFold(limits, "exempt") {
+			policy.Exempt = true
+		} else {
+			var rules []string
+			for _, rule := range strings.Split(limits, ",") {
+				mode, found := strings.CutPrefix(strings.TrimSpace(rule), "failure:")
+				if !found {
+					rules = append(rules, rule)
+					continue
+				}
+				if mode = strings.ToLower(mode); !domain.ValidFailureMode(mode) {
+					return nil, fmt.Errorf("invalid route policy %q: failure mode must be open, closed or memory", item)
+				}
+				policy.FailureMode = mode
+			}
+			parsed, err := ParseLimits(strings.Join(rules, ","))
+			if err != nil {
+				return nil, fmt.Errorf("invalid route policy %q: %w", item, err)
+			}
+			policy.Limits = parsed
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+func ParseLimitTiers(value string) ([]domain.LimitTier, error) {
+	var tiers []domain.LimitTier
+	for _, item := range strings.Split(value, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, limits, found := strings.Cut(item, "=")
+		name, limits = strings.TrimSpace(name), strings.TrimSpace(limits)
+		if !found || name == "" || limits == "" {
+			return nil, fmt.Errorf("invalid limit tier %q: expected <tier>=<limits>", item)
+		}
+		rules, err := ParseLimits(limits)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit tier %q: %w", item, err)
+		}
+		tiers = append(tiers, domain.LimitTier{Name: name, Limits: rules})
+	}
+	return tiers, nil
+}
+
+func ParseNetworks(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, item := range parseList(value) {
+		if !strings.Contains(item, "/") {
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid network %q: %w", item, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", item, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseCount(value string) (int64, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	multiplier := 1.0
+	if number, found := strings.CutSuffix(value, "k"); found {
+		value, multiplier = number, 1e3
+	} else if number, found := strings.CutSuffix(value, "m"); found {
+		value, multiplier = number, 1e6
+	}
+
+	count, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if count*multiplier < 1 {
+		return 0, fmt.Errorf("request count must be at least 1")
+	}
+	return int64(count * multiplier), nil
+}
+
+func parseLimitsEnv(key string) []domain.LimitRule {
+	rules, err := ParseLimits(getEnv(key, ""))
+	if err != nil {
+		log.Println("Invalid", key, "ignoring it:", err)
+		return nil
+	}
+	return rules
+}
+
+// legacyWindow keeps deployments that still set the deprecated
+// TTL_EXPIRATION_SECONDS working: it is the default window whenever
+// RATE_LIMIT_WINDOW is not set.
+func legacyWindow() string {
+	ttl, set := os.LookupEnv("TTL_EXPIRATION_SECONDS")
+	if !set {
+		return "1s"
+	}
+	if _, replaced := os.LookupEnv("RATE_LIMIT_WINDOW"); replaced {
+		log.Println("TTL_EXPIRATION_SECONDS is deprecated and ignored because RATE_LIMIT_WINDOW is set")
+		return "1s"
+	}
+	seconds, err := strconv.Atoi(strings.TrimSpace(ttl))
+	if err != nil || seconds <= 0 {
+		log.Println("Invalid TTL_EXPIRATION_SECONDS, using 1s:", ttl)
+		return "1s"
+	}
+	log.Println("TTL_EXPIRATION_SECONDS is deprecated, use RATE_LIMIT_WINDOW; using a window of", seconds, "seconds")
+	return strconv.Itoa(seconds) + "s"
+}
+
+func parseWindowEnv(key, defaultValue string) time.Duration {
+	window, err := ParseWindow(getEnv(key, defaultValue))
+	if err != nil {
+		log.Println("Invalid", key, "using 1s:", err)
+		return time.Second
 	}
+	return window
 }
 
 func getEnv(key, defaultValue string) string {
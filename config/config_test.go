@@ -0,0 +1,202 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{value: "1s", expected: time.Second},
+		{value: "500ms", expected: 500 * time.Millisecond},
+		{value: "1m30s", expected: 90 * time.Second},
+		{value: "min", expected: time.Minute},
+		{value: "Hour", expected: time.Hour},
+		{value: "day", expected: 24 * time.Hour},
+		{value: "7d", expected: 7 * 24 * time.Hour},
+		{value: "0.5d", expected: 12 * time.Hour},
+	}
+
+	for _, test := range tests {
+		window, err := ParseWindow(test.value)
+		if err != nil {
+			t.Fatalf("ParseWindow(%q): unexpected error: %v", test.value, err)
+		}
+		if window != test.expected {
+			t.Fatalf("ParseWindow(%q): expected %v, got %v", test.value, test.expected, window)
+		}
+	}
+
+	for _, value := range []string{"", "0s", "-1m", "abc", "xd"} {
+		if _, err := ParseWindow(value); err == nil {
+			t.Fatalf("ParseWindow(%q): expected an error", value)
+		}
+	}
+}
+
+func TestLegacyWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      string
+		window   string
+		expected string
+	}{
+		{name: "unset", expected: "1s"},
+		{name: "ttl only", ttl: "5", expected: "5s"},
+		{name: "window wins", ttl: "5", window: "1m", expected: "1s"},
+		{name: "invalid ttl", ttl: "soon", expected: "1s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range map[string]string{"TTL_EXPIRATION_SECONDS": tt.ttl, "RATE_LIMIT_WINDOW": tt.window} {
+				t.Setenv(key, value)
+				if value == "" {
+					os.Unsetenv(key)
+				}
+			}
+			if got := legacyWindow(); got != tt.expected {
+				t.Fatalf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	rules, err := ParseLimits("10/s, 300/min,10k/day")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []domain.LimitRule{
+		{MaxRequests: 10, Window: time.Second},
+		{MaxRequests: 300, Window: time.Minute},
+		{MaxRequests: 10000, Window: 24 * time.Hour},
+	}
+	if len(rules) != len(expected) {
+		t.Fatalf("Expected %d rules, got %+v", len(expected), rules)
+	}
+	for i := range expected {
+		if rules[i] != expected[i] {
+			t.Fatalf("Rule %d: expected %+v, got %+v", i, expected[i], rules[i])
+		}
+	}
+
+	if rules, err := ParseLimits(""); err != nil || rules != nil {
+		t.Fatalf("Expected no rules for an empty value, got %+v (%v)", rules, err)
+	}
+	for _, value := range []string{"10", "0/s", "abc/min", "10/forever"} {
+		if _, err := ParseLimits(value); err == nil {
+			t.Fatalf("ParseLimits(%q): expected an error", value)
+		}
+	}
+}
+
+func TestParseRouteCosts(t *testing.T) {
+	costs, err := ParseRouteCosts("get /export=10, /search=query:page_size,POST /upload=body:1024")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]string{
+		"GET /export":  "10",
+		"/search":      "query:page_size",
+		"POST /upload": "body:1024",
+	}
+	if len(costs) != len(expected) {
+		t.Fatalf("Expected %d routes, got %+v", len(expected), costs)
+	}
+	for route, cost := range expected {
+		if costs[route] != cost {
+			t.Fatalf("Route %q: expected %q, got %q", route, cost, costs[route])
+		}
+	}
+
+	for _, value := range []string{"/export", "=10", "/export="} {
+		if _, err := ParseRouteCosts(value); err == nil {
+			t.Fatalf("ParseRouteCosts(%q): expected an error", value)
+		}
+	}
+}
+
+func TestParseRoutePolicies(t *testing.T) {
+	policies, err := ParseRoutePolicies("post /orders=2/s; GET /*=50/s,1k/h;/health=exempt")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(policies) != 3 {
+		t.Fatalf("Expected 3 policies, got %+v", policies)
+	}
+
+	orders := policies[0]
+	if orders.Name != "post/orders" || orders.Method != "POST" || orders.Pattern != "/orders" || len(orders.Limits) != 1 || orders.Limits[0].MaxRequests != 2 {
+		t.Fatalf("Unexpected orders policy: %+v", orders)
+	}
+	if all := policies[1]; all.Name != "get/*" || len(all.Limits) != 2 || all.Limits[1] != (domain.LimitRule{MaxRequests: 1000, Window: time.Hour}) {
+		t.Fatalf("Unexpected catch-all policy: %+v", all)
+	}
+	if health := policies[2]; health.Name != "/health" || health.Method != "" || !health.Exempt || health.Limits != nil {
+		t.Fatalf("Unexpected health policy: %+v", health)
+	}
+
+	policies, err = ParseRoutePolicies("POST /login=5/m,failure:closed;/health=failure:OPEN")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if login := policies[0]; login.FailureMode != domain.FailureModeClosed || len(login.Limits) != 1 || login.Limits[0].MaxRequests != 5 {
+		t.Fatalf("Unexpected login policy: %+v", login)
+	}
+	if health := policies[1]; health.FailureMode != domain.FailureModeOpen || health.Limits != nil {
+		t.Fatalf("Unexpected health policy: %+v", health)
+	}
+
+	for _, value := range []string{"/orders", "orders=2/s", "/orders=2", "/orders=", "/orders=2/s,failure:sometimes"} {
+		if _, err := ParseRoutePolicies(value); err == nil {
+			t.Fatalf("ParseRoutePolicies(%q): expected an error", value)
+		}
+	}
+}
+
+func TestParseLimitTiers(t *testing.T) {
+	tiers, err := ParseLimitTiers("free=10/m; pro=100/s,10k/d")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(tiers) != 2 || tiers[0].Name != "free" || tiers[0].Limits[0] != (domain.LimitRule{MaxRequests: 10, Window: time.Minute}) {
+		t.Fatalf("Unexpected tiers: %+v", tiers)
+	}
+	if pro := tiers[1]; pro.Name != "pro" || len(pro.Limits) != 2 || pro.Limits[1] != (domain.LimitRule{MaxRequests: 10000, Window: 24 * time.Hour}) {
+		t.Fatalf("Unexpected pro tier: %+v", pro)
+	}
+
+	for _, value := range []string{"free", "=10/m", "free=", "free=10"} {
+		if _, err := ParseLimitTiers(value); err == nil {
+			t.Fatalf("ParseLimitTiers(%q): expected an error", value)
+		}
+	}
+}
+
+func TestParseNetworks(t *testing.T) {
+	prefixes, err := ParseNetworks("10.0.0.0/8, 192.168.1.7,2001:db8::/32, 172.16.5.4/12")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"10.0.0.0/8", "192.168.1.7/32", "2001:db8::/32", "172.16.0.0/12"}
+	if len(prefixes) != len(expected) {
+		t.Fatalf("Expected %d prefixes, got %v", len(expected), prefixes)
+	}
+	for i := range expected {
+		if prefixes[i].String() != expected[i] {
+			t.Fatalf("Prefix %d: expected %s, got %s", i, expected[i], prefixes[i])
+		}
+	}
+
+	for _, value := range []string{"10.0.0.0/33", "proxy.local", "10.0.0/8"} {
+		if _, err := ParseNetworks(value); err == nil {
+			t.Fatalf("ParseNetworks(%q): expected an error", value)
+		}
+	}
+}
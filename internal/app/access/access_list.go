@@ -0,0 +1,138 @@
+package access
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"go.uber.org/zap"
+)
+
+type AccessList struct {
+	allow     []netip.Prefix
+	deny      []netip.Prefix
+	store     domain.AccessListStore
+	trie      atomic.Pointer[prefixTrie]
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewAccessList(allow, deny []netip.Prefix) *AccessList {
+	return NewStoreAccessList(nil, allow, deny, 0)
+}
+
+func NewStoreAccessList(store domain.AccessListStore, allow, deny []netip.Prefix, refreshInterval time.Duration) *AccessList {
+	a := &AccessList{
+		allow: allow,
+		deny:  deny,
+		store: store,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	a.trie.Store(buildTrie(allow, deny))
+	if store != nil {
+		if err := a.Refresh(); err != nil {
+			logger.Error("Failed to load access lists, using configured networks only", err)
+		}
+	}
+	if store != nil && refreshInterval > 0 {
+		go a.refresher(refreshInterval)
+	} else {
+		close(a.done)
+	}
+	return a
+}
+
+func (a *AccessList) Access(addr netip.Addr) string {
+	return a.trie.Load().lookup(addr)
+}
+
+func (a *AccessList) Refresh() error {
+	if a.store == nil {
+		return nil
+	}
+
+	allowed, denied, err := a.store.AccessNetworks(context.Background())
+	if err != nil {
+		return err
+	}
+	allow := append(append([]netip.Prefix{}, a.allow...), parseNetworks(allowed, domain.AccessAllow)...)
+	deny := append(append([]netip.Prefix{}, a.deny...), parseNetworks(denied, domain.AccessDeny)...)
+	trie := buildTrie(allow, deny)
+	a.trie.Store(trie)
+
+	logger.Debug("Access lists refreshed", zap.Int("allow", len(allow)), zap.Int("deny", len(deny)), zap.Int("networks", trie.size))
+	return nil
+}
+
+func (a *AccessList) Close() error {
+	a.closeOnce.Do(func() {
+		close(a.stop)
+	})
+	<-a.done
+	return nil
+}
+
+func (a *AccessList) refresher(interval time.Duration) {
+	defer close(a.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := a.Refresh(); err != nil {
+				logger.Error("Failed to refresh access lists, keeping the previous ones", err)
+			}
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+func buildTrie(allow, deny []netip.Prefix) *prefixTrie {
+	trie := &prefixTrie{}
+	for _, prefix := range allow {
+		trie.insert(prefix, domain.AccessAllow)
+	}
+	for _, prefix := range deny {
+		trie.insert(prefix, domain.AccessDeny)
+	}
+	return trie
+}
+
+func parseNetworks(networks []string, list string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(networks))
+	for _, network := range networks {
+		prefix, err := parseNetwork(network)
+		if err != nil {
+			logger.Error("Ignoring invalid access list entry", err, zap.String("list", list), zap.String("network", network))
+			continue
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes
+}
+
+func parseNetwork(network string) (netip.Prefix, error) {
+	network = strings.TrimSpace(network)
+	if !strings.Contains(network, "/") {
+		addr, err := netip.ParseAddr(network)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+	}
+
+	prefix, err := netip.ParsePrefix(network)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix.Masked(), nil
+}
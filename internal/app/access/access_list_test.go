@@ -0,0 +1,84 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+type fakeAccessStore struct {
+	allow []string
+	deny  []string
+	err   error
+}
+
+func (f *fakeAccessStore) AccessNetworks(ctx context.Context) ([]string, []string, error) {
+	return f.allow, f.deny, f.err
+}
+
+func prefixes(networks ...string) []netip.Prefix {
+	result := make([]netip.Prefix, len(networks))
+	for i, network := range networks {
+		result[i] = netip.MustParsePrefix(network)
+	}
+	return result
+}
+
+func TestAccessList_Lookup(t *testing.T) {
+	accessList := NewAccessList(
+		prefixes("10.0.0.0/8", "10.1.2.3/32", "2001:db8::/32", "192.168.0.0/16"),
+		prefixes("10.1.0.0/16", "2001:db8:bad::/48", "192.168.0.0/16"),
+	)
+
+	tests := []struct {
+		addr     string
+		expected string
+	}{
+		{addr: "10.2.3.4", expected: domain.AccessAllow},
+		{addr: "10.1.9.9", expected: domain.AccessDeny},
+		{addr: "10.1.2.3", expected: domain.AccessAllow},
+		{addr: "::ffff:10.1.9.9", expected: domain.AccessDeny},
+		{addr: "192.168.1.1", expected: domain.AccessDeny},
+		{addr: "2001:db8:1::1", expected: domain.AccessAllow},
+		{addr: "2001:db8:bad::1", expected: domain.AccessDeny},
+		{addr: "172.16.0.1", expected: ""},
+		{addr: "2001:db9::1", expected: ""},
+	}
+	for _, tt := range tests {
+		if got := accessList.Access(netip.MustParseAddr(tt.addr)); got != tt.expected {
+			t.Fatalf("Access(%s): expected %q, got %q", tt.addr, tt.expected, got)
+		}
+	}
+}
+
+func TestAccessList_Refresh(t *testing.T) {
+	store := &fakeAccessStore{deny: []string{"203.0.113.0/24", "not-a-network"}}
+	accessList := NewStoreAccessList(store, prefixes("198.51.100.0/24"), nil, 0)
+	defer accessList.Close()
+
+	if got := accessList.Access(netip.MustParseAddr("203.0.113.7")); got != domain.AccessDeny {
+		t.Fatalf("Expected networks from the store to be denied, got %q", got)
+	}
+	if got := accessList.Access(netip.MustParseAddr("198.51.100.7")); got != domain.AccessAllow {
+		t.Fatalf("Expected configured networks to be kept, got %q", got)
+	}
+
+	store.allow, store.deny = []string{"203.0.113.7"}, nil
+	if err := accessList.Refresh(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := accessList.Access(netip.MustParseAddr("203.0.113.7")); got != domain.AccessAllow {
+		t.Fatalf("Expected the refreshed lists to apply, got %q", got)
+	}
+
+	store.err = errors.New("redis unavailable")
+	if err := accessList.Refresh(); err == nil {
+		t.Fatalf("Expected the store error to be returned")
+	}
+	if got := accessList.Access(netip.MustParseAddr("203.0.113.7")); got != domain.AccessAllow {
+		t.Fatalf("A failed refresh should keep the previous lists, got %q", got)
+	}
+}
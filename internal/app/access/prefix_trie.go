@@ -0,0 +1,76 @@
+package access
+
+import (
+	"net/netip"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+type trieNode struct {
+	children [2]*trieNode
+	action   string
+}
+
+type prefixTrie struct {
+	ipv4 trieNode
+	ipv6 trieNode
+	size int
+}
+
+func (t *prefixTrie) insert(prefix netip.Prefix, action string) {
+	prefix = unmapPrefix(prefix.Masked())
+	addr := prefix.Addr()
+	node := t.root(addr)
+	for i := 0; i < prefix.Bits(); i++ {
+		bit := addressBit(addr, i)
+		if node.children[bit] == nil {
+			node.children[bit] = &trieNode{}
+		}
+		node = node.children[bit]
+	}
+	if node.action == "" {
+		t.size++
+	}
+	if node.action != domain.AccessDeny {
+		node.action = action
+	}
+}
+
+func (t *prefixTrie) lookup(addr netip.Addr) string {
+	addr = addr.Unmap()
+	node := t.root(addr)
+	action := node.action
+	for i := 0; i < addr.BitLen(); i++ {
+		node = node.children[addressBit(addr, i)]
+		if node == nil {
+			break
+		}
+		if node.action != "" {
+			action = node.action
+		}
+	}
+	return action
+}
+
+func (t *prefixTrie) root(addr netip.Addr) *trieNode {
+	if addr.Is4() {
+		return &t.ipv4
+	}
+	return &t.ipv6
+}
+
+func addressBit(addr netip.Addr, i int) int {
+	bytes := addr.As16()
+	offset := 0
+	if addr.Is4() {
+		offset = 12
+	}
+	return int(bytes[offset+i/8]>>(7-i%8)) & 1
+}
+
+func unmapPrefix(prefix netip.Prefix) netip.Prefix {
+	if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+		return netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+	}
+	return prefix
+}
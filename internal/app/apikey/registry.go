@@ -0,0 +1,169 @@
+package apikey
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/config"
+	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"go.uber.org/zap"
+)
+
+type fileEntry struct {
+	Hash   string `json:"hash"`
+	Owner  string `json:"owner"`
+	Plan   string `json:"plan"`
+	Limits string `json:"limits"`
+}
+
+const defaultCacheSize = 10000
+
+type RegistryConfig struct {
+	// CacheTTL is how long a store lookup, found or not, is reused before the
+	// store is asked again. Zero disables the cache.
+	CacheTTL  time.Duration
+	CacheSize int
+}
+
+type cachedKey struct {
+	key       domain.APIKey
+	found     bool
+	expiresAt time.Time
+}
+
+type Registry struct {
+	keys      map[string]domain.APIKey
+	store     domain.APIKeyStore
+	cacheTTL  time.Duration
+	cacheSize int
+	mu        sync.Mutex
+	cache     map[string]cachedKey
+	now       func() time.Time
+}
+
+func NewRegistry(keys []domain.APIKey, store domain.APIKeyStore) *Registry {
+	return NewRegistryWithConfig(keys, store, RegistryConfig{})
+}
+
+func NewRegistryWithConfig(keys []domain.APIKey, store domain.APIKeyStore, config RegistryConfig) *Registry {
+	if config.CacheSize <= 0 {
+		config.CacheSize = defaultCacheSize
+	}
+	registry := &Registry{
+		keys:      make(map[string]domain.APIKey, len(keys)),
+		store:     store,
+		cacheTTL:  config.CacheTTL,
+		cacheSize: config.CacheSize,
+		cache:     make(map[string]cachedKey),
+		now:       time.Now,
+	}
+	for _, key := range keys {
+		registry.keys[key.Hash] = key
+	}
+	return registry
+}
+
+func HashKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (r *Registry) Lookup(ctx context.Context, token string) (domain.APIKey, bool, error) {
+	hash := HashKey(token)
+	if key, ok := r.keys[hash]; ok {
+		return key, true, nil
+	}
+	if r.store == nil {
+		return domain.APIKey{}, false, nil
+	}
+	if cached, ok := r.cached(hash); ok {
+		return cached.key, cached.found, nil
+	}
+
+	record, found, err := r.store.APIKey(ctx, hash)
+	if err != nil {
+		return domain.APIKey{}, false, err
+	}
+	if !found {
+		r.remember(hash, domain.APIKey{}, false)
+		return domain.APIKey{}, false, nil
+	}
+	key := domain.APIKey{Hash: hash, Owner: record.Owner, Plan: record.Plan}
+	if key.Limits, err = config.ParseLimits(record.Limits); err != nil {
+		logger.Error("Ignoring invalid API key limits", err, zap.String("owner", record.Owner), zap.String("limits", record.Limits))
+		key.Limits = nil
+	}
+	r.remember(hash, key, true)
+	return key, true, nil
+}
+
+func (r *Registry) cached(hash string) (cachedKey, bool) {
+	if r.cacheTTL <= 0 {
+		return cachedKey{}, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cached, ok := r.cache[hash]
+	if !ok || !r.now().Before(cached.expiresAt) {
+		return cachedKey{}, false
+	}
+	return cached, true
+}
+
+// remember caches a store lookup. A full cache first drops expired entries
+// and, if still full, an arbitrary one.
+func (r *Registry) remember(hash string, key domain.APIKey, found bool) {
+	if r.cacheTTL <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	if _, exists := r.cache[hash]; !exists && len(r.cache) >= r.cacheSize {
+		for cachedHash, cached := range r.cache {
+			if !now.Before(cached.expiresAt) {
+				delete(r.cache, cachedHash)
+			}
+		}
+		for cachedHash := range r.cache {
+			if len(r.cache) < r.cacheSize {
+				break
+			}
+			delete(r.cache, cachedHash)
+		}
+	}
+	r.cache[hash] = cachedKey{key: key, found: found, expiresAt: now.Add(r.cacheTTL)}
+}
+
+func LoadFile(path string) ([]domain.APIKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []fileEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	keys := make([]domain.APIKey, len(entries))
+	for i, entry := range entries {
+		hash := strings.ToLower(strings.TrimSpace(entry.Hash))
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("%s: entry %d: hash must be a hex-encoded SHA-256 digest", path, i+1)
+		}
+		limits, err := config.ParseLimits(entry.Limits)
+		if err != nil {
+			return nil, fmt.Errorf("%s: entry %d: %w", path, i+1, err)
+		}
+		keys[i] = domain.APIKey{Hash: hash, Owner: entry.Owner, Plan: entry.Plan, Limits: limits}
+	}
+	return keys, nil
+}
@@ -0,0 +1,118 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+type fakeAPIKeyStore struct {
+	records map[string]domain.APIKeyRecord
+	err     error
+	calls   int
+}
+
+func (f *fakeAPIKeyStore) APIKey(ctx context.Context, hash string) (domain.APIKeyRecord, bool, error) {
+	f.calls++
+	record, ok := f.records[hash]
+	return record, ok, f.err
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api_keys.json")
+	content := `[{"hash": "` + HashKey("abc") + `", "owner": "acme", "plan": "pro", "limits": "100/s,10k/d"}]`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write API keys: %v", err)
+	}
+
+	keys, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(keys) != 1 || keys[0].Owner != "acme" || keys[0].Plan != "pro" || len(keys[0].Limits) != 2 || keys[0].Limits[1] != (domain.LimitRule{MaxRequests: 10000, Window: 24 * time.Hour}) {
+		t.Fatalf("Unexpected keys: %+v", keys)
+	}
+
+	for _, content := range []string{`[{"hash": "abc"}]`, `[{"hash": "` + HashKey("abc") + `", "limits": "100"}]`, `{`} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write API keys: %v", err)
+		}
+		if _, err := LoadFile(path); err == nil {
+			t.Fatalf("Expected %s to be rejected", content)
+		}
+	}
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	store := &fakeAPIKeyStore{records: map[string]domain.APIKeyRecord{
+		HashKey("redis-key"):  {Owner: "globex", Plan: "free", Limits: "5/m"},
+		HashKey("bad-limits"): {Owner: "initech", Plan: "free", Limits: "lots"},
+	}}
+	registry := NewRegistry([]domain.APIKey{{Hash: HashKey("file-key"), Owner: "acme", Plan: "pro"}}, store)
+
+	if key, found, err := registry.Lookup(context.Background(), "file-key"); err != nil || !found || key.Owner != "acme" {
+		t.Fatalf("Expected the file key to be found, got %+v %v (%v)", key, found, err)
+	}
+	key, found, err := registry.Lookup(context.Background(), "redis-key")
+	if err != nil || !found || key.Plan != "free" || key.Hash != HashKey("redis-key") || key.Limits[0] != (domain.LimitRule{MaxRequests: 5, Window: time.Minute}) {
+		t.Fatalf("Expected the Redis key to be found, got %+v %v (%v)", key, found, err)
+	}
+	if key, found, _ := registry.Lookup(context.Background(), "bad-limits"); !found || key.Limits != nil {
+		t.Fatalf("Invalid limits should be ignored, got %+v", key)
+	}
+	if _, found, err := registry.Lookup(context.Background(), "invented"); err != nil || found {
+		t.Fatalf("Unknown keys should not be found, got %v (%v)", found, err)
+	}
+
+	store.err = errors.New("redis unavailable")
+	if _, _, err := registry.Lookup(context.Background(), "invented"); err == nil {
+		t.Fatalf("Expected the store error to be returned")
+	}
+}
+
+func TestRegistry_CachesStoreLookups(t *testing.T) {
+	store := &fakeAPIKeyStore{records: map[string]domain.APIKeyRecord{
+		HashKey("redis-key"): {Owner: "globex", Plan: "free"},
+	}}
+	now := time.Unix(1700000000, 0)
+	registry := NewRegistryWithConfig(nil, store, RegistryConfig{CacheTTL: 30 * time.Second, CacheSize: 2})
+	registry.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if key, found, err := registry.Lookup(context.Background(), "redis-key"); err != nil || !found || key.Owner != "globex" {
+			t.Fatalf("Lookup %d: expected the Redis key, got %+v %v (%v)", i+1, key, found, err)
+		}
+		if _, found, err := registry.Lookup(context.Background(), "invented"); err != nil || found {
+			t.Fatalf("Lookup %d: expected an unknown key, got %v (%v)", i+1, found, err)
+		}
+	}
+	if store.calls != 2 {
+		t.Fatalf("Expected found and unknown keys to be cached, got %d store calls", store.calls)
+	}
+
+	store.records[HashKey("invented")] = domain.APIKeyRecord{Owner: "initech"}
+	now = now.Add(31 * time.Second)
+	if key, found, _ := registry.Lookup(context.Background(), "invented"); !found || key.Owner != "initech" {
+		t.Fatalf("Expected an expired entry to be looked up again, got %+v %v", key, found)
+	}
+
+	registry.Lookup(context.Background(), "third")
+	if len(registry.cache) != 2 {
+		t.Fatalf("Expected the cache to stay within its size, got %d entries", len(registry.cache))
+	}
+
+	store.err = errors.New("redis unavailable")
+	if _, _, err := registry.Lookup(context.Background(), "fourth"); err == nil {
+		t.Fatalf("Expected the store error to be returned")
+	}
+	store.err = nil
+	registry.Lookup(context.Background(), "fourth")
+	if store.calls != 6 {
+		t.Fatalf("Expected store errors not to be cached, got %d store calls", store.calls)
+	}
+}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signToken(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	t.Helper()
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	encode := func(value any) string {
+		data, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("Failed to encode token: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := encode(header) + "." + encode(claims)
+	if key == nil {
+		return signed + "."
+	}
+
+	hash := algorithms[alg].hash
+	hasher := hash.New()
+	hasher.Write([]byte(signed))
+	digest := hasher.Sum(nil)
+
+	var signature []byte
+	var err error
+	switch key := key.(type) {
+	case []byte:
+		mac := hmac.New(hash.New, key)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		if alg[:2] == "PS" {
+			signature, err = rsa.SignPSS(rand.Reader, key, hash, digest, nil)
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, key, hash, digest)
+		}
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key, digest)
+		size := (key.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+	}
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerifier_Algorithms(t *testing.T) {
+	secret := []byte("s3cr3t")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+	verifier := NewVerifier(VerifierConfig{Keys: []Key{
+		{Secret: secret},
+		{ID: "rsa-1", Public: &rsaKey.PublicKey},
+		{ID: "ec-1", Public: &ecKey.PublicKey},
+	}})
+	claims := map[string]any{"sub": "user-1", "plan": "pro"}
+
+	tests := []struct {
+		alg string
+		kid string
+		key any
+	}{
+		{alg: "HS256", key: secret},
+		{alg: "HS512", key: secret},
+		{alg: "RS256", kid: "rsa-1", key: rsaKey},
+		{alg: "PS384", key: rsaKey},
+		{alg: "ES256", kid: "ec-1", key: ecKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			got, err := verifier.Verify(signToken(t, tt.alg, tt.kid, tt.key, claims))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.String("sub") != "user-1" || got.String("plan") != "pro" {
+				t.Fatalf("Unexpected claims: %+v", got)
+			}
+		})
+	}
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	token := strings.Split(signToken(t, "HS256", "", secret, claims), ".")
+	forged := strings.Split(signToken(t, "HS256", "", secret, map[string]any{"sub": "admin"}), ".")
+	rejected := map[string]string{
+		"alg none":        signToken(t, "none", "", nil, claims),
+		"wrong key":       signToken(t, "RS256", "", otherKey, claims),
+		"wrong kid":       signToken(t, "RS256", "ec-1", rsaKey, claims),
+		"wrong secret":    signToken(t, "HS256", "", []byte("guess"), claims),
+		"tampered claims": token[0] + "." + forged[1] + "." + token[2],
+		"malformed":       "not-a-token",
+	}
+	for name, token := range rejected {
+		if _, err := verifier.Verify(token); err == nil {
+			t.Fatalf("%s: expected the token to be rejected", name)
+		}
+	}
+}
+
+func TestVerifier_RejectsKeyConfusion(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	verifier := NewVerifier(VerifierConfig{Keys: []Key{{Public: &rsaKey.PublicKey}}})
+
+	forged := signToken(t, "HS256", "", rsaKey.PublicKey.N.Bytes(), map[string]any{"sub": "admin"})
+	if _, err := verifier.Verify(forged); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("HMAC tokens must not be verified with public keys, got %v", err)
+	}
+}
+
+func TestVerifier_Claims(t *testing.T) {
+	secret := []byte("s3cr3t")
+	now := time.Unix(1700000000, 0)
+	verifier := NewVerifier(VerifierConfig{
+		Keys:     []Key{{Secret: secret}},
+		Issuer:   "https://auth.example.com",
+		Audience: "rate-limiter",
+		Leeway:   30 * time.Second,
+	})
+	verifier.now = func() time.Time { return now }
+
+	valid := map[string]any{
+		"sub": "user-1",
+		"iss": "https://auth.example.com",
+		"aud": []string{"billing", "rate-limiter"},
+		"exp": now.Unix() + 60,
+		"nbf": now.Unix() + 10,
+	}
+	if _, err := verifier.Verify(signToken(t, "HS256", "", secret, valid)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		claim    string
+		value    any
+		expected error
+	}{
+		{name: "Expired", claim: "exp", value: now.Unix() - 30, expected: ErrTokenExpired},
+		{name: "Not valid yet", claim: "nbf", value: now.Unix() + 31, expected: ErrTokenNotYetValid},
+		{name: "Wrong issuer", claim: "iss", value: "https://evil.example.com", expected: ErrInvalidIssuer},
+		{name: "Wrong audience", claim: "aud", value: "billing", expected: ErrInvalidAudience},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := make(map[string]any, len(valid))
+			for name, value := range valid {
+				claims[name] = value
+			}
+			claims[tt.claim] = tt.value
+			if _, err := verifier.Verify(signToken(t, "HS256", "", secret, claims)); !errors.Is(err, tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+var ErrNoKeys = errors.New("no verification keys found")
+
+type jwk struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+	K         string `json:"k"`
+}
+
+func LoadKeyFiles(secretFile, publicKeyFile, jwksFile string) ([]Key, error) {
+	var keys []Key
+	if secretFile != "" {
+		key, err := LoadSecretFile(secretFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if publicKeyFile != "" {
+		publicKeys, err := LoadPublicKeyFile(publicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, publicKeys...)
+	}
+	if jwksFile != "" {
+		jwks, err := LoadJWKSFile(jwksFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, jwks...)
+	}
+	return keys, nil
+}
+
+func LoadSecretFile(path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, err
+	}
+	secret := bytes.TrimSpace(data)
+	if len(secret) == 0 {
+		return Key{}, fmt.Errorf("%s: %w", path, ErrNoKeys)
+	}
+	return Key{Secret: secret}, nil
+}
+
+func LoadPublicKeyFile(path string) ([]Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []Key
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		public, err := parsePublicKeyBlock(block)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, Key{Public: public})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: %w", path, ErrNoKeys)
+	}
+	return keys, nil
+}
+
+func LoadJWKSFile(path string) ([]Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return keys, nil
+}
+
+func ParseJWKS(data []byte) ([]Key, error) {
+	var document struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	var keys []Key
+	for _, entry := range document.Keys {
+		if entry.Use != "" && entry.Use != "sig" {
+			continue
+		}
+		key, err := entry.key()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", entry.KeyID, err)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	return keys, nil
+}
+
+func (j jwk) key() (Key, error) {
+	key := Key{ID: j.KeyID, Algorithm: j.Algorithm}
+	switch j.KeyType {
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(j.K)
+		if err != nil || len(secret) == 0 {
+			return Key{}, errors.New("invalid symmetric key")
+		}
+		key.Secret = secret
+	case "RSA":
+		n, errN := decodeBigInt(j.N)
+		e, errE := decodeBigInt(j.E)
+		if errN != nil || errE != nil || !e.IsInt64() {
+			return Key{}, errors.New("invalid RSA key")
+		}
+		key.Public = &rsa.PublicKey{N: n, E: int(e.Int64())}
+	case "EC":
+		curve, ok := curves[j.Curve]
+		if !ok {
+			return Key{}, fmt.Errorf("unsupported curve %q", j.Curve)
+		}
+		x, errX := decodeBigInt(j.X)
+		y, errY := decodeBigInt(j.Y)
+		if errX != nil || errY != nil || !curve.IsOnCurve(x, y) {
+			return Key{}, errors.New("invalid EC key")
+		}
+		key.Public = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	default:
+		return Key{}, fmt.Errorf("unsupported key type %q", j.KeyType)
+	}
+	return key, nil
+}
+
+var curves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+func parsePublicKeyBlock(block *pem.Block) (any, error) {
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return certificate.PublicKey, nil
+	}
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadKeyFiles(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	jwksKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to encode EC key: %v", err)
+	}
+	publicKeys := append(
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)})...,
+	)
+
+	encode := func(value []byte) string {
+		return base64.RawURLEncoding.EncodeToString(value)
+	}
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "alg": "RS256", "use": "sig", "n": encode(jwksKey.N.Bytes()), "e": encode(big.NewInt(int64(jwksKey.E)).Bytes())},
+		{"kty": "oct", "kid": "hmac-1", "k": encode([]byte("jwks-secret"))},
+		{"kty": "RSA", "kid": "enc-1", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}})
+
+	keys, err := LoadKeyFiles(
+		writeFile(t, "secret", []byte("file-secret\n")),
+		writeFile(t, "public.pem", publicKeys),
+		writeFile(t, "jwks.json", jwks),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(keys) != 5 {
+		t.Fatalf("Expected 5 keys, got %d", len(keys))
+	}
+
+	verifier := NewVerifier(VerifierConfig{Keys: keys})
+	claims := map[string]any{"sub": "user-1"}
+	tokens := map[string]string{
+		"file secret": signToken(t, "HS256", "", []byte("file-secret"), claims),
+		"PEM EC key":  signToken(t, "ES384", "", ecKey, claims),
+		"PEM RSA key": signToken(t, "RS512", "", rsaKey, claims),
+		"JWKS RSA":    signToken(t, "RS256", "rsa-1", jwksKey, claims),
+		"JWKS secret": signToken(t, "HS384", "hmac-1", []byte("jwks-secret"), claims),
+	}
+	for name, token := range tokens {
+		if _, err := verifier.Verify(token); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+	}
+	if _, err := verifier.Verify(signToken(t, "RS512", "rsa-1", jwksKey, claims)); err == nil {
+		t.Fatalf("Expected JWKS keys to be restricted to their algorithm")
+	}
+}
+
+func TestLoadKeyFiles_Errors(t *testing.T) {
+	if _, err := LoadSecretFile(writeFile(t, "secret", []byte("\n"))); !errors.Is(err, ErrNoKeys) {
+		t.Fatalf("Expected an empty secret to be rejected, got %v", err)
+	}
+	if _, err := LoadPublicKeyFile(writeFile(t, "public.pem", []byte("not pem"))); !errors.Is(err, ErrNoKeys) {
+		t.Fatalf("Expected a file without PEM blocks to be rejected, got %v", err)
+	}
+	if _, err := ParseJWKS([]byte(`{"keys":[{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}]}`)); err == nil {
+		t.Fatalf("Expected points off the curve to be rejected")
+	}
+	if _, err := LoadJWKSFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatalf("Expected a missing JWKS file to be rejected")
+	}
+}
@@ -0,0 +1,43 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"go.uber.org/zap"
+)
+
+var ErrBlocksUnsupported = errors.New("store does not support dedicated blocks")
+
+type storeBlockManager struct {
+	blocks domain.BlockStore
+}
+
+func (s storeBlockManager) ListBlocks(ctx context.Context) ([]domain.Block, error) {
+	if s.blocks == nil {
+		return nil, ErrBlocksUnsupported
+	}
+	return s.blocks.ListBlocks(ctx)
+}
+
+func (s storeBlockManager) LiftBlock(ctx context.Context, key string) error {
+	if s.blocks == nil {
+		return ErrBlocksUnsupported
+	}
+	logger.Info("Lifting block", zap.String("key", key))
+	return s.blocks.DeleteBlock(ctx, key)
+}
+
+func (s storeBlockManager) setBlock(ctx context.Context, key, reason string, duration int64) error {
+	if s.blocks == nil {
+		return ErrBlocksUnsupported
+	}
+	logger.Debug("Blocking key", zap.String("key", key), zap.String("reason", reason), zap.Int64("duration", duration))
+	if err := s.blocks.SetBlock(ctx, key, reason, duration); err != nil {
+		logger.Error("Store SetBlock failed", err, zap.String("key", key))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package limiter
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// Closers collects the limiters that run background work, such as the
+// janitor of the memory limiters, so they can all be stopped on shutdown.
+type Closers struct {
+	mu      sync.Mutex
+	closers map[io.Closer]struct{}
+}
+
+func NewClosers() *Closers {
+	return &Closers{closers: make(map[io.Closer]struct{})}
+}
+
+// Track registers every resource that is an io.Closer; others are ignored.
+func (c *Closers) Track(resources ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, resource := range resources {
+		if closer, ok := resource.(io.Closer); ok {
+			c.closers[closer] = struct{}{}
+		}
+	}
+}
+
+// Release closes a tracked resource that is no longer used.
+func (c *Closers) Release(resource any) error {
+	closer, ok := resource.(io.Closer)
+	if !ok {
+		return nil
+	}
+	c.mu.Lock()
+	delete(c.closers, closer)
+	c.mu.Unlock()
+	return closer.Close()
+}
+
+func (c *Closers) Close() error {
+	c.mu.Lock()
+	closers := c.closers
+	c.closers = make(map[io.Closer]struct{})
+	c.mu.Unlock()
+
+	var errs []error
+	for closer := range closers {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
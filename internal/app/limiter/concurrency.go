@@ -0,0 +1,52 @@
+package limiter
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+const (
+	defaultLeaseDuration    = time.Minute
+	defaultConcurrencyRetry = time.Second
+)
+
+// ErrLeaseExpired reports a renewal for a lease that already expired or was
+// released, so its slot may have been handed to another request.
+var ErrLeaseExpired = errors.New("concurrency lease expired")
+
+func concurrencyLimitFor(config domain.LimiterConfig, isToken bool) int {
+	if isToken {
+		return config.TokenMaxConcurrent
+	}
+	return config.MaxConcurrent
+}
+
+func leaseFor(config domain.LimiterConfig) time.Duration {
+	if config.LeaseDuration > 0 {
+		return config.LeaseDuration
+	}
+	return defaultLeaseDuration
+}
+
+func newLeaseID() string {
+	return fmt.Sprintf("%d-%d", time.Now().UnixNano(), rand.Int63())
+}
+
+func concurrencyDecision(result domain.ConcurrencyResult, limit int64, isToken bool) domain.Decision {
+	decision := domain.Decision{
+		Allowed:   result.Acquired,
+		Limit:     limit,
+		Remaining: max(0, limit-result.InFlight),
+		Policy:    policyFor(isToken) + "-concurrency",
+		Reason:    domain.ReasonAllowed,
+	}
+	if !result.Acquired {
+		decision.Reason = domain.ReasonConcurrencyExceeded
+		decision.RetryAfter = defaultConcurrencyRetry
+	}
+	return decision
+}
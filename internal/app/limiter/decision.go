@@ -0,0 +1,90 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func policyFor(isToken bool) string {
+	if isToken {
+		return domain.PolicyToken
+	}
+	return domain.PolicyIP
+}
+
+func reasonFor(allowed, blocked bool) string {
+	switch {
+	case allowed:
+		return domain.ReasonAllowed
+	case blocked:
+		return domain.ReasonBlocked
+	default:
+		return domain.ReasonLimitExceeded
+	}
+}
+
+func decisionFromResult(result domain.StoreResult, limit int64, window time.Duration, isToken bool, now time.Time) domain.Decision {
+	return domain.Decision{
+		Allowed:    result.Allowed,
+		Limit:      limit,
+		Window:     window,
+		Remaining:  result.Remaining,
+		ResetAt:    now.Add(result.ResetAfter),
+		RetryAfter: result.RetryAfter,
+		Policy:     policyFor(isToken),
+		Reason:     reasonFor(result.Allowed, result.Blocked),
+	}
+}
+
+func oversizedDecision(cost, limit int64, window time.Duration, isToken bool, now time.Time) (domain.Decision, bool) {
+	if cost <= limit {
+		return domain.Decision{}, false
+	}
+	return decisionFromResult(domain.StoreResult{RetryAfter: window, ResetAfter: window}, limit, window, isToken, now), true
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
+
+var ErrCostUnsupported = errors.New("limiter does not support weighted costs")
+
+type DecisionAdapter struct {
+	domain.Limiter
+}
+
+func NewDecisionAdapter(limiter domain.Limiter) domain.WeightedLimiter {
+	if weightedLimiter, ok := limiter.(domain.WeightedLimiter); ok {
+		return weightedLimiter
+	}
+	return &DecisionAdapter{Limiter: limiter}
+}
+
+func (a *DecisionAdapter) Evaluate(ctx context.Context, key string, isToken bool) (domain.Decision, error) {
+	if decisionLimiter, ok := a.Limiter.(domain.DecisionLimiter); ok {
+		return decisionLimiter.Evaluate(ctx, key, isToken)
+	}
+	allowed, err := a.AllowRequest(ctx, key, isToken)
+	if err != nil {
+		return domain.Decision{}, err
+	}
+
+	return domain.Decision{
+		Allowed: allowed,
+		Policy:  policyFor(isToken),
+		Reason:  reasonFor(allowed, false),
+	}, nil
+}
+
+// EvaluateCost charges a plain Limiter one request at a time, so it refuses
+// any cost above one rather than silently under-charging it.
+func (a *DecisionAdapter) EvaluateCost(ctx context.Context, key string, isToken bool, cost int64) (domain.Decision, error) {
+	if cost > 1 {
+		return domain.Decision{}, ErrCostUnsupported
+	}
+	return a.Evaluate(ctx, key, isToken)
+}
@@ -0,0 +1,160 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+type boolLimiter struct {
+	allowed bool
+}
+
+func (b *boolLimiter) AllowRequest(ctx context.Context, key string, isToken bool) (bool, error) {
+	return b.allowed, nil
+}
+
+func (b *boolLimiter) BlockKey(ctx context.Context, key string, duration int64) error {
+	return nil
+}
+
+func TestDecisionAdapter(t *testing.T) {
+	memoryLimiter := NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 1})
+	if adapted := NewDecisionAdapter(memoryLimiter); adapted != domain.DecisionLimiter(memoryLimiter) {
+		t.Fatalf("Limiters that already evaluate decisions should not be wrapped")
+	}
+
+	adapted := NewDecisionAdapter(&boolLimiter{allowed: false})
+	decision, err := adapted.Evaluate(context.Background(), "abc", true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decision.Allowed || decision.Policy != domain.PolicyToken || decision.Reason != domain.ReasonLimitExceeded {
+		t.Fatalf("Unexpected adapted decision: %+v", decision)
+	}
+
+	if allowed, _ := adapted.AllowRequest(context.Background(), "abc", true); allowed {
+		t.Fatalf("Adapter should keep the old AllowRequest signature")
+	}
+}
+
+func TestDecisionAdapter_RefusesWeightedCosts(t *testing.T) {
+	adapted := NewDecisionAdapter(&boolLimiter{allowed: true})
+	if decision, err := adapted.EvaluateCost(context.Background(), "abc", true, 1); err != nil || !decision.Allowed {
+		t.Fatalf("A unit cost should reach the wrapped limiter, got %+v (%v)", decision, err)
+	}
+	if _, err := adapted.EvaluateCost(context.Background(), "abc", true, 5); !errors.Is(err, ErrCostUnsupported) {
+		t.Fatalf("Expected ErrCostUnsupported for a weighted cost, got %v", err)
+	}
+}
+
+func TestMemoryLimiters_EvaluateCost(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	clock := func() time.Time { return now }
+	config := domain.LimiterConfig{
+		MaxRequests: 10,
+		Window:      time.Minute,
+		Limits:      []domain.LimitRule{{MaxRequests: 10, Window: time.Minute}},
+	}
+
+	window := NewMemoryRateLimiter(config)
+	window.now = clock
+	tokenBucket := NewMemoryTokenBucketLimiter(config)
+	tokenBucket.now = clock
+	gcra := NewMemoryGCRALimiter(config)
+	gcra.now = clock
+	slidingWindow := NewMemorySlidingWindowLimiter(config)
+	slidingWindow.now = clock
+	stacked := NewMemoryStackedLimiter(config)
+	stacked.now = clock
+
+	limiters := map[string]domain.WeightedLimiter{
+		domain.StrategySlidingLog:    window,
+		domain.StrategyTokenBucket:   tokenBucket,
+		domain.StrategyGCRA:          gcra,
+		domain.StrategySlidingWindow: slidingWindow,
+		domain.StrategyStacked:       stacked,
+	}
+
+	for strategy, rateLimiter := range limiters {
+		t.Run(strategy, func(t *testing.T) {
+			steps := []struct {
+				cost      int64
+				allowed   bool
+				remaining int64
+			}{
+				{cost: 4, allowed: true, remaining: 6},
+				{cost: 4, allowed: true, remaining: 2},
+				{cost: 4, allowed: false},
+				{cost: 2, allowed: true, remaining: 0},
+				{cost: 1, allowed: false},
+			}
+			for i, step := range steps {
+				decision, err := rateLimiter.EvaluateCost(context.Background(), "192.168.1.1", false, step.cost)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if decision.Allowed != step.allowed || (step.allowed && decision.Remaining != step.remaining) {
+					t.Fatalf("Step %d (cost %d): expected allowed=%v remaining=%d, got %+v", i+1, step.cost, step.allowed, step.remaining, decision)
+				}
+				if !decision.Allowed && decision.RetryAfter <= 0 {
+					t.Fatalf("Step %d: denied decisions should carry a retry delay, got %+v", i+1, decision)
+				}
+			}
+		})
+	}
+
+	if decision, _ := window.EvaluateCost(context.Background(), "10.0.0.1", false, 11); decision.Allowed || decision.RetryAfter != time.Minute {
+		t.Fatalf("A cost above the limit should never be allowed, got %+v", decision)
+	}
+}
+
+func TestLimiters_OversizedCost(t *testing.T) {
+	config := domain.LimiterConfig{
+		MaxRequests: 10,
+		Window:      time.Minute,
+		Limits:      []domain.LimitRule{{MaxRequests: 10, Window: time.Minute}},
+	}
+	limiters := map[string]domain.WeightedLimiter{
+		"memory-" + domain.StrategySlidingLog:    NewMemoryRateLimiter(config),
+		"memory-" + domain.StrategyTokenBucket:   NewMemoryTokenBucketLimiter(config),
+		"memory-" + domain.StrategyGCRA:          NewMemoryGCRALimiter(config),
+		"memory-" + domain.StrategySlidingWindow: NewMemorySlidingWindowLimiter(config),
+		"memory-" + domain.StrategyStacked:       NewMemoryStackedLimiter(config),
+		"redis-" + domain.StrategyWindow:         NewRedisRateLimiter(&MockAtomicRedisStore{}, config),
+		"redis-" + domain.StrategyTokenBucket:    NewRedisTokenBucketLimiter(&MockTokenBucketStore{}, config),
+		"redis-" + domain.StrategyGCRA:           NewRedisGCRALimiter(&MockGCRAStore{}, config),
+		"redis-" + domain.StrategySlidingLog:     NewRedisSlidingLogLimiter(&MockSlidingWindowStore{}, config),
+		"redis-" + domain.StrategySlidingWindow:  NewRedisSlidingWindowLimiter(&MockSlidingWindowStore{}, config),
+		"redis-" + domain.StrategyStacked:        NewRedisStackedLimiter(&MockStackedLimitStore{}, config),
+	}
+
+	for name, rateLimiter := range limiters {
+		t.Run(name, func(t *testing.T) {
+			for _, cost := range []int64{11, math.MaxInt64} {
+				decision, err := rateLimiter.EvaluateCost(context.Background(), "192.168.1.1", false, cost)
+				if err != nil {
+					t.Fatalf("Cost %d: oversized costs should be denied without reaching the store, got %v", cost, err)
+				}
+				if decision.Allowed || decision.Reason != domain.ReasonLimitExceeded || decision.RetryAfter <= 0 {
+					t.Fatalf("Cost %d: expected a denial, got %+v", cost, decision)
+				}
+			}
+		})
+	}
+
+	for name, rateLimiter := range limiters {
+		if !strings.HasPrefix(name, "memory-") {
+			continue
+		}
+		decision, err := rateLimiter.EvaluateCost(context.Background(), "192.168.1.1", false, 10)
+		if err != nil || !decision.Allowed || decision.Remaining != 0 {
+			t.Fatalf("%s: oversized costs should not consume the budget, got %+v (%v)", name, decision, err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package limiter
+
+import (
+	"math"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func ScaleConfig(config domain.LimiterConfig, factor float64) domain.LimiterConfig {
+	if factor <= 0 || factor >= 1 {
+		return config
+	}
+
+	config.MaxRequests = scaleCount(config.MaxRequests, factor)
+	config.TokenMaxRequests = scaleCount(config.TokenMaxRequests, factor)
+	config.BurstCapacity = scaleCount(config.BurstCapacity, factor)
+	config.TokenBurstCapacity = scaleCount(config.TokenBurstCapacity, factor)
+	config.Limits = scaleRules(config.Limits, factor)
+	config.TokenLimits = scaleRules(config.TokenLimits, factor)
+	return config
+}
+
+func scaleRules(rules []domain.LimitRule, factor float64) []domain.LimitRule {
+	if rules == nil {
+		return nil
+	}
+	scaled := make([]domain.LimitRule, len(rules))
+	for i, rule := range rules {
+		scaled[i] = domain.LimitRule{MaxRequests: scaleCount(rule.MaxRequests, factor), Window: rule.Window}
+	}
+	return scaled
+}
+
+func scaleCount[T int | int64](count T, factor float64) T {
+	if count <= 0 {
+		return count
+	}
+	return max(1, T(math.Floor(float64(count)*factor)))
+}
@@ -0,0 +1,29 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func TestScaleConfig(t *testing.T) {
+	config := domain.LimiterConfig{
+		MaxRequests:      10,
+		TokenMaxRequests: 3,
+		BurstCapacity:    0,
+		Limits:           []domain.LimitRule{{MaxRequests: 100, Window: time.Second}},
+		MaxConcurrent:    8,
+	}
+
+	scaled := ScaleConfig(config, 0.25)
+	if scaled.MaxRequests != 2 || scaled.TokenMaxRequests != 1 || scaled.BurstCapacity != 0 || scaled.MaxConcurrent != 8 {
+		t.Fatalf("Unexpected scaled config: %+v", scaled)
+	}
+	if scaled.Limits[0] != (domain.LimitRule{MaxRequests: 25, Window: time.Second}) || config.Limits[0].MaxRequests != 100 {
+		t.Fatalf("Expected limits to be scaled without changing the original, got %+v / %+v", scaled.Limits, config.Limits)
+	}
+	if unscaled := ScaleConfig(config, 1); unscaled.MaxRequests != 10 {
+		t.Fatalf("A factor of 1 should keep the limits, got %+v", unscaled)
+	}
+}
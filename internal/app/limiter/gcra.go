@@ -0,0 +1,24 @@
+package limiter
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func emissionIntervalFor(config domain.LimiterConfig, isToken bool) time.Duration {
+	limit := limitFor(config, isToken)
+	if limit <= 0 {
+		return 0
+	}
+	return windowFor(config, isToken) / time.Duration(limit)
+}
+
+func blockDelayFor(config domain.LimiterConfig, isToken bool, duration time.Duration) time.Duration {
+	return duration + time.Duration(burstFor(config, isToken)-1)*emissionIntervalFor(config, isToken)
+}
+
+func isTokenKey(prefixedKey string) bool {
+	return strings.HasPrefix(prefixedKey, "token:")
+}
@@ -0,0 +1,56 @@
+package limiter
+
+import (
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+const defaultWindow = time.Second
+
+func prefixKey(key string, isToken bool) string {
+	if isToken {
+		return "token:" + key
+	}
+	return "ip:" + key
+}
+
+func limitFor(config domain.LimiterConfig, isToken bool) int {
+	if isToken {
+		return config.TokenMaxRequests
+	}
+	return config.MaxRequests
+}
+
+func burstFor(config domain.LimiterConfig, isToken bool) int {
+	burst := config.BurstCapacity
+	if isToken {
+		burst = config.TokenBurstCapacity
+	}
+	if burst <= 0 {
+		return limitFor(config, isToken)
+	}
+	return burst
+}
+
+func windowFor(config domain.LimiterConfig, isToken bool) time.Duration {
+	if isToken && config.TokenWindow > 0 {
+		return config.TokenWindow
+	}
+	if config.Window > 0 {
+		return config.Window
+	}
+	return defaultWindow
+}
+
+func refillRateFor(config domain.LimiterConfig, isToken bool) float64 {
+	return float64(limitFor(config, isToken)) / windowFor(config, isToken).Seconds()
+}
+
+func burstWindowFor(config domain.LimiterConfig, isToken bool) time.Duration {
+	limit := limitFor(config, isToken)
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(burstFor(config, isToken)) * windowFor(config, isToken) / time.Duration(limit)
+}
@@ -0,0 +1,91 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+type MemoryConcurrencyLimiter struct {
+	mu     sync.Mutex
+	leases map[string]map[string]time.Time
+	config domain.LimiterConfig
+	now    func() time.Time
+}
+
+func NewMemoryConcurrencyLimiter(config domain.LimiterConfig) *MemoryConcurrencyLimiter {
+	return &MemoryConcurrencyLimiter{
+		leases: make(map[string]map[string]time.Time),
+		config: config,
+		now:    clockFor(config),
+	}
+}
+
+func (m *MemoryConcurrencyLimiter) Acquire(ctx context.Context, key string, isToken bool) (domain.Decision, domain.Lease, error) {
+	limit := int64(concurrencyLimitFor(m.config, isToken))
+	if limit <= 0 {
+		return domain.Decision{Allowed: true, Reason: domain.ReasonAllowed}, domain.Lease{}, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prefixedKey := prefixKey(key, isToken)
+	now := m.now()
+	leases, exists := m.leases[prefixedKey]
+	if !exists {
+		leases = make(map[string]time.Time)
+		m.leases[prefixedKey] = leases
+	}
+	for id, expiresAt := range leases {
+		if !now.Before(expiresAt) {
+			delete(leases, id)
+		}
+	}
+
+	result := domain.ConcurrencyResult{InFlight: int64(len(leases))}
+	if result.InFlight >= limit {
+		return concurrencyDecision(result, limit, isToken), domain.Lease{}, nil
+	}
+
+	lease := domain.Lease{Key: prefixedKey, ID: newLeaseID(), Duration: leaseFor(m.config)}
+	leases[lease.ID] = now.Add(leaseFor(m.config))
+	result.Acquired = true
+	result.InFlight++
+	return concurrencyDecision(result, limit, isToken), lease, nil
+}
+
+func (m *MemoryConcurrencyLimiter) Renew(ctx context.Context, lease domain.Lease) error {
+	if lease.ID == "" {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	expiresAt, exists := m.leases[lease.Key][lease.ID]
+	if !exists || !now.Before(expiresAt) {
+		return ErrLeaseExpired
+	}
+	m.leases[lease.Key][lease.ID] = now.Add(leaseFor(m.config))
+	return nil
+}
+
+func (m *MemoryConcurrencyLimiter) Release(ctx context.Context, lease domain.Lease) error {
+	if lease.ID == "" {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	leases := m.leases[lease.Key]
+	delete(leases, lease.ID)
+	if len(leases) == 0 {
+		delete(m.leases, lease.Key)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func TestMemoryConcurrencyLimiter(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	concurrencyLimiter := NewMemoryConcurrencyLimiter(domain.LimiterConfig{
+		MaxConcurrent: 2,
+		LeaseDuration: 30 * time.Second,
+	})
+	concurrencyLimiter.now = func() time.Time { return now }
+
+	var leases []domain.Lease
+	for i := 0; i < 2; i++ {
+		decision, lease, err := concurrencyLimiter.Acquire(context.Background(), "192.168.1.1", false)
+		if err != nil || !decision.Allowed || lease.ID == "" {
+			t.Fatalf("Slot %d should be acquired, got %+v %+v (%v)", i+1, decision, lease, err)
+		}
+		leases = append(leases, lease)
+	}
+
+	decision, lease, _ := concurrencyLimiter.Acquire(context.Background(), "192.168.1.1", false)
+	if decision.Allowed || lease.ID != "" || decision.Reason != domain.ReasonConcurrencyExceeded || decision.Policy != "ip-concurrency" {
+		t.Fatalf("Expected the third in-flight request to be denied, got %+v", decision)
+	}
+	if decision, _, _ := concurrencyLimiter.Acquire(context.Background(), "192.168.1.2", false); !decision.Allowed {
+		t.Fatalf("Slots should be tracked per key, got %+v", decision)
+	}
+
+	if err := concurrencyLimiter.Release(context.Background(), leases[0]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	decision, _, _ = concurrencyLimiter.Acquire(context.Background(), "192.168.1.1", false)
+	if !decision.Allowed || decision.Remaining != 0 {
+		t.Fatalf("Releasing a slot should allow a new request, got %+v", decision)
+	}
+
+	now = now.Add(31 * time.Second)
+	if decision, _, _ := concurrencyLimiter.Acquire(context.Background(), "192.168.1.1", false); !decision.Allowed || decision.Remaining != 1 {
+		t.Fatalf("Expired leases should free their slots, got %+v", decision)
+	}
+
+	if decision, lease, _ := concurrencyLimiter.Acquire(context.Background(), "abc", true); !decision.Allowed || lease.ID != "" {
+		t.Fatalf("Tokens without a concurrency limit should not hold slots, got %+v %+v", decision, lease)
+	}
+}
+
+func TestMemoryConcurrencyLimiter_Renew(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	concurrencyLimiter := NewMemoryConcurrencyLimiter(domain.LimiterConfig{
+		MaxConcurrent: 1,
+		LeaseDuration: 30 * time.Second,
+	})
+	concurrencyLimiter.now = func() time.Time { return now }
+
+	_, lease, _ := concurrencyLimiter.Acquire(context.Background(), "192.168.1.1", false)
+	if lease.Duration != 30*time.Second {
+		t.Fatalf("Expected the lease to carry its duration, got %+v", lease)
+	}
+
+	now = now.Add(20 * time.Second)
+	if err := concurrencyLimiter.Renew(context.Background(), lease); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	now = now.Add(20 * time.Second)
+	if decision, _, _ := concurrencyLimiter.Acquire(context.Background(), "192.168.1.1", false); decision.Allowed {
+		t.Fatalf("A renewed lease should keep its slot past the original expiry, got %+v", decision)
+	}
+
+	now = now.Add(11 * time.Second)
+	if err := concurrencyLimiter.Renew(context.Background(), lease); !errors.Is(err, ErrLeaseExpired) {
+		t.Fatalf("Expected ErrLeaseExpired for an expired lease, got %v", err)
+	}
+	if err := concurrencyLimiter.Renew(context.Background(), domain.Lease{}); err != nil {
+		t.Fatalf("Renewing an empty lease should be a no-op, got %v", err)
+	}
+}
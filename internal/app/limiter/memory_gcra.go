@@ -0,0 +1,119 @@
+package limiter
+
+import (
+	"context"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+type gcraState struct {
+	tat time.Time
+}
+
+type MemoryGCRALimiter struct {
+	store  *memoryStore[*gcraState]
+	config domain.LimiterConfig
+	now    func() time.Time
+}
+
+func NewMemoryGCRALimiter(config domain.LimiterConfig) *MemoryGCRALimiter {
+	m := &MemoryGCRALimiter{
+		store:  newMemoryStore[*gcraState](config.MaxKeys, defaultMemoryShards),
+		config: config,
+		now:    clockFor(config),
+	}
+	m.store.runJanitor(time.Duration(config.CleanupInterval)*time.Second, m.sweep)
+	return m
+}
+
+func (m *MemoryGCRALimiter) AllowRequest(ctx context.Context, key string, isToken bool) (bool, error) {
+	decision, err := m.Evaluate(ctx, key, isToken)
+	return decision.Allowed, err
+}
+
+func (m *MemoryGCRALimiter) Evaluate(ctx context.Context, key string, isToken bool) (domain.Decision, error) {
+	return m.EvaluateCost(ctx, key, isToken, 1)
+}
+
+func (m *MemoryGCRALimiter) EvaluateCost(ctx context.Context, key string, isToken bool, cost int64) (domain.Decision, error) {
+	prefixedKey := prefixKey(key, isToken)
+	now := m.now()
+	interval := emissionIntervalFor(m.config, isToken)
+	if interval == 0 {
+		return decisionFromResult(domain.StoreResult{}, 0, burstWindowFor(m.config, isToken), isToken, now), nil
+	}
+	burst := burstFor(m.config, isToken)
+	if decision, oversized := oversizedDecision(cost, int64(burst), burstWindowFor(m.config, isToken), isToken, now); oversized {
+		return decision, nil
+	}
+
+	shard := m.store.shardFor(prefixedKey)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	state := m.store.entry(shard, prefixedKey, newGCRAState)
+	tat := state.tat
+	if tat.Before(now) {
+		tat = now
+	}
+
+	newTat := tat.Add(time.Duration(cost) * interval)
+	allowAt := newTat.Add(-time.Duration(burst) * interval)
+	if now.Before(allowAt) {
+		result := domain.StoreResult{RetryAfter: allowAt.Sub(now), ResetAfter: tat.Sub(now)}
+		if tat.Sub(now) > time.Duration(burst)*interval {
+			result.Blocked = true
+		} else if m.config.BlockDuration > 0 {
+			block := time.Duration(m.config.BlockDuration) * time.Second
+			blockedTat := now.Add(blockDelayFor(m.config, isToken, block))
+			state.tat = blockedTat
+			result.RetryAfter = block
+			result.ResetAfter = blockedTat.Sub(now)
+		}
+		return decisionFromResult(result, int64(burst), burstWindowFor(m.config, isToken), isToken, now), nil
+	}
+
+	state.tat = newTat
+	result := domain.StoreResult{
+		Allowed:    true,
+		Remaining:  int64(now.Sub(allowAt) / interval),
+		ResetAfter: newTat.Sub(now),
+	}
+	return decisionFromResult(result, int64(burst), burstWindowFor(m.config, isToken), isToken, now), nil
+}
+
+func (m *MemoryGCRALimiter) BlockKey(ctx context.Context, key string, duration int64) error {
+	shard := m.store.shardFor(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	now := m.now()
+	state := m.store.entry(shard, key, newGCRAState)
+	blockedTat := now.Add(blockDelayFor(m.config, isTokenKey(key), time.Duration(duration)*time.Second))
+	if blockedTat.After(state.tat) {
+		state.tat = blockedTat
+	}
+	return nil
+}
+
+func (m *MemoryGCRALimiter) Stats() MemoryStats {
+	return m.store.stats()
+}
+
+func (m *MemoryGCRALimiter) Close() error {
+	return m.store.Close()
+}
+
+// sweep drops keys whose theoretical arrival time has passed, since they
+// would start over from now anyway.
+func (m *MemoryGCRALimiter) sweep() {
+	now := m.now()
+	m.store.sweep(now, func(key string, state *gcraState) bool {
+		return !state.tat.After(now)
+	})
+}
+
+func newGCRAState() *gcraState {
+	return &gcraState{}
+}
@@ -0,0 +1,88 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func TestMemoryGCRALimiter(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rateLimiter := NewMemoryGCRALimiter(domain.LimiterConfig{
+		MaxRequests:      4,
+		TokenMaxRequests: 10,
+		BurstCapacity:    2,
+	})
+	rateLimiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		decision, _ := rateLimiter.Evaluate(context.Background(), "10.0.0.1", false)
+		if !decision.Allowed {
+			t.Fatalf("Request %d should have been allowed within the burst", i+1)
+		}
+		if decision.Remaining != int64(1-i) {
+			t.Fatalf("Request %d: expected %d remaining, got %d", i+1, 1-i, decision.Remaining)
+		}
+	}
+
+	decision, _ := rateLimiter.Evaluate(context.Background(), "10.0.0.1", false)
+	if decision.Allowed {
+		t.Fatalf("Request beyond the burst should have been denied")
+	}
+	if decision.RetryAfter != 250*time.Millisecond {
+		t.Fatalf("Expected retry after 250ms, got %v", decision.RetryAfter)
+	}
+	if decision.Reason != domain.ReasonLimitExceeded {
+		t.Fatalf("Expected reason %s, got %s", domain.ReasonLimitExceeded, decision.Reason)
+	}
+
+	now = now.Add(decision.RetryAfter)
+	if decision, _ := rateLimiter.Evaluate(context.Background(), "10.0.0.1", false); !decision.Allowed {
+		t.Fatalf("Request should have been allowed after the retry-after interval")
+	}
+
+	if keys := rateLimiter.store.keys.Load(); keys != 1 {
+		t.Fatalf("Expected a single stored timestamp, got %d", keys)
+	}
+}
+
+func TestMemoryGCRALimiter_Block(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rateLimiter := NewMemoryGCRALimiter(domain.LimiterConfig{
+		MaxRequests:   1,
+		BlockDuration: 10,
+	})
+	rateLimiter.now = func() time.Time { return now }
+
+	if allowed, _ := rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false); !allowed {
+		t.Fatalf("First request should have been allowed")
+	}
+
+	decision, _ := rateLimiter.Evaluate(context.Background(), "10.0.0.1", false)
+	if decision.Allowed {
+		t.Fatalf("Second request should have been denied")
+	}
+	if decision.RetryAfter != 10*time.Second {
+		t.Fatalf("Expected retry after the block duration, got %v", decision.RetryAfter)
+	}
+
+	now = now.Add(5 * time.Second)
+	decision, _ = rateLimiter.Evaluate(context.Background(), "10.0.0.1", false)
+	if decision.Allowed || decision.Reason != domain.ReasonBlocked {
+		t.Fatalf("Request should still be blocked, got %+v", decision)
+	}
+
+	now = now.Add(10 * time.Second)
+	if allowed, _ := rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false); !allowed {
+		t.Fatalf("Request should be allowed after the block duration")
+	}
+
+	if err := rateLimiter.BlockKey(context.Background(), "token:abc", 5); err != nil {
+		t.Fatalf("Failed to block key: %v", err)
+	}
+	if allowed, _ := rateLimiter.AllowRequest(context.Background(), "abc", true); allowed {
+		t.Fatalf("Request should be denied for an explicitly blocked key")
+	}
+}
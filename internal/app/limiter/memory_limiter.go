@@ -1,69 +1,203 @@
 package limiter
 
 import (
-	"sync"
+	"context"
 	"time"
+	"unsafe"
 
+	"github.com/ankardo/Rate-Limiter/config/logger"
 	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"go.uber.org/zap"
 )
 
+type requestLog struct {
+	timestamps []time.Time
+}
+
+type MemoryStats struct {
+	Keys        int
+	Blocks      int
+	Timestamps  int
+	Evictions   int64
+	Sweeps      int64
+	ApproxBytes int64
+}
+
 type MemoryRateLimiter struct {
-	mu       sync.Mutex
-	requests map[string][]time.Time
-	limits   map[string]time.Time
-	config   domain.LimiterConfig
+	store  *memoryStore[*requestLog]
+	config domain.LimiterConfig
+	now    func() time.Time
 }
 
 func NewMemoryRateLimiter(config domain.LimiterConfig) *MemoryRateLimiter {
-	return &MemoryRateLimiter{
-		requests: make(map[string][]time.Time),
-		limits:   make(map[string]time.Time),
-		config:   config,
+	return newMemoryRateLimiter(config, defaultMemoryShards)
+}
+
+func newMemoryRateLimiter(config domain.LimiterConfig, shardCount int) *MemoryRateLimiter {
+	m := &MemoryRateLimiter{
+		store:  newMemoryStore[*requestLog](config.MaxKeys, shardCount),
+		config: config,
+		now:    clockFor(config),
 	}
+	m.store.runJanitor(time.Duration(config.CleanupInterval)*time.Second, func() {
+		m.sweep()
+		stats := m.Stats()
+		logger.Debug("Memory limiter sweep",
+			zap.Int("keys", stats.Keys),
+			zap.Int("blocks", stats.Blocks),
+			zap.Int64("evictions", stats.Evictions),
+			zap.Int64("approxBytes", stats.ApproxBytes),
+		)
+	})
+	return m
+}
+
+func (m *MemoryRateLimiter) AllowRequest(ctx context.Context, key string, isToken bool) (bool, error) {
+	decision, err := m.Evaluate(ctx, key, isToken)
+	return decision.Allowed, err
 }
 
-func (m *MemoryRateLimiter) AllowRequest(key string, isToken bool) (bool, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+func (m *MemoryRateLimiter) Evaluate(ctx context.Context, key string, isToken bool) (domain.Decision, error) {
+	return m.EvaluateCost(ctx, key, isToken, 1)
+}
 
-	prefixedKey := key
-	if isToken {
-		prefixedKey = "token:" + key
-	} else {
-		prefixedKey = "ip:" + key
+func (m *MemoryRateLimiter) EvaluateCost(ctx context.Context, key string, isToken bool, cost int64) (domain.Decision, error) {
+	prefixedKey := prefixKey(key, isToken)
+	shard := m.store.shardFor(prefixedKey)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	limit := limitFor(m.config, isToken)
+	window := windowFor(m.config, isToken)
+	now := m.now()
+	if decision, oversized := oversizedDecision(cost, int64(limit), window, isToken, now); oversized {
+		return decision, nil
+	}
+	decision := domain.Decision{
+		Limit:  int64(limit),
+		Window: window,
+		Policy: policyFor(isToken),
 	}
 
-	if _, exists := m.requests[prefixedKey]; !exists {
-		m.requests[prefixedKey] = []time.Time{}
+	if blockedUntil, blocked := m.store.blockedUntil(shard, prefixedKey, now); blocked {
+		decision.Reason = domain.ReasonBlocked
+		decision.ResetAt = blockedUntil
+		decision.RetryAfter = blockedUntil.Sub(now)
+		return decision, nil
 	}
 
-	now := time.Now()
-	windowStart := now.Add(-time.Second)
+	entry := m.store.entry(shard, prefixedKey, newRequestLog)
+	entry.timestamps = pruneBefore(entry.timestamps, now.Add(-window))
+	filtered := entry.timestamps
 
-	filtered := []time.Time{}
-	for _, t := range m.requests[prefixedKey] {
-		if t.After(windowStart) {
-			filtered = append(filtered, t)
+	if int64(len(filtered))+cost > int64(limit) {
+		decision.Reason = domain.ReasonLimitExceeded
+		decision.ResetAt = now.Add(window)
+		if expiring := int64(len(filtered)) + cost - int64(limit) - 1; expiring < int64(len(filtered)) {
+			decision.ResetAt = filtered[expiring].Add(window)
 		}
+		if m.config.BlockDuration > 0 {
+			decision.ResetAt, _ = m.store.block(shard, prefixedKey, domain.ReasonLimitExceeded, now, time.Duration(m.config.BlockDuration)*time.Second)
+		}
+		decision.RetryAfter = decision.ResetAt.Sub(now)
+		return decision, nil
 	}
-	m.requests[prefixedKey] = filtered
 
-	limit := m.config.MaxRequests
-	if isToken {
-		limit = m.config.TokenMaxRequests
+	for range cost {
+		entry.timestamps = append(entry.timestamps, now)
 	}
+	decision.Allowed = true
+	decision.Reason = domain.ReasonAllowed
+	decision.Remaining = int64(limit - len(entry.timestamps))
+	decision.ResetAt = entry.timestamps[0].Add(window)
+	return decision, nil
+}
 
-	if len(filtered) >= limit {
-		return false, nil
+func (m *MemoryRateLimiter) BlockKey(ctx context.Context, key string, duration int64) error {
+	shard := m.store.shardFor(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if _, blocked := m.store.block(shard, key, domain.BlockReasonManual, m.now(), time.Duration(duration)*time.Second); !blocked {
+		return ErrMemoryStoreFull
 	}
+	return nil
+}
+
+func (m *MemoryRateLimiter) BlockedUntil(key string) (time.Time, bool) {
+	shard := m.store.shardFor(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	return m.store.blockedUntil(shard, key, m.now())
+}
 
-	m.requests[prefixedKey] = append(m.requests[prefixedKey], now)
-	return true, nil
+func (m *MemoryRateLimiter) ListBlocks(ctx context.Context) ([]domain.Block, error) {
+	var blocks []domain.Block
+	for _, shard := range m.store.shards {
+		shard.mu.Lock()
+		now := m.now()
+		for key, entry := range shard.limits {
+			if _, blocked := m.store.blockedUntil(shard, key, now); !blocked {
+				continue
+			}
+			blocks = append(blocks, domain.Block{
+				Key:       key,
+				Reason:    entry.reason,
+				ExpiresIn: entry.until.Sub(now),
+			})
+		}
+		shard.mu.Unlock()
+	}
+	return blocks, nil
 }
 
-func (m *MemoryRateLimiter) BlockKey(key string, duration int64) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.limits[key] = time.Now().Add(time.Duration(duration) * time.Second)
+func (m *MemoryRateLimiter) LiftBlock(ctx context.Context, key string) error {
+	shard := m.store.shardFor(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	m.store.unblock(shard, key)
 	return nil
 }
+
+func (m *MemoryRateLimiter) Stats() MemoryStats {
+	stats := m.store.stats()
+	for _, shard := range m.store.shards {
+		shard.mu.Lock()
+		for element := shard.lru.Front(); element != nil; element = element.Next() {
+			entry := element.Value.(*memoryEntry[*requestLog])
+			stats.Timestamps += len(entry.value.timestamps)
+			stats.ApproxBytes += int64(len(entry.key)) + int64(cap(entry.value.timestamps))*int64(unsafe.Sizeof(time.Time{})) + int64(unsafe.Sizeof(memoryEntry[*requestLog]{})+unsafe.Sizeof(requestLog{}))
+		}
+		for key := range shard.limits {
+			stats.ApproxBytes += int64(len(key)) + int64(unsafe.Sizeof(memoryBlock{}))
+		}
+		shard.mu.Unlock()
+	}
+	return stats
+}
+
+func (m *MemoryRateLimiter) Close() error {
+	return m.store.Close()
+}
+
+func (m *MemoryRateLimiter) sweep() {
+	now := m.now()
+	m.store.sweep(now, func(key string, log *requestLog) bool {
+		log.timestamps = pruneBefore(log.timestamps, now.Add(-windowFor(m.config, isTokenKey(key))))
+		return len(log.timestamps) == 0
+	})
+}
+
+func newRequestLog() *requestLog {
+	return &requestLog{}
+}
+
+func pruneBefore(timestamps []time.Time, windowStart time.Time) []time.Time {
+	i := 0
+	for i < len(timestamps) && !timestamps[i].After(windowStart) {
+		i++
+	}
+	if i == 0 {
+		return timestamps
+	}
+	return append(timestamps[:0], timestamps[i:]...)
+}
@@ -0,0 +1,53 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+var benchmarkConfig = domain.LimiterConfig{
+	MaxRequests:      100,
+	TokenMaxRequests: 1000,
+	MaxKeys:          100000,
+}
+
+func benchmarkMemoryRateLimiter(b *testing.B, rateLimiter *MemoryRateLimiter, keys int) {
+	var worker int64
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		id := atomic.AddInt64(&worker, 1)
+		workerKeys := make([]string, keys)
+		for i := range workerKeys {
+			workerKeys[i] = fmt.Sprintf("10.%d.%d", id, i)
+		}
+		i := 0
+		for pb.Next() {
+			rateLimiter.AllowRequest(context.Background(), workerKeys[i%keys], false)
+			i++
+		}
+	})
+}
+
+func BenchmarkMemoryRateLimiter_Parallel(b *testing.B) {
+	for _, shards := range []int{1, defaultMemoryShards} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			rateLimiter := newMemoryRateLimiter(benchmarkConfig, shards)
+			benchmarkMemoryRateLimiter(b, rateLimiter, 1024)
+		})
+	}
+}
+
+func BenchmarkMemoryRateLimiter_HotKey(b *testing.B) {
+	rateLimiter := NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 100, TokenMaxRequests: 100})
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false)
+		}
+	})
+}
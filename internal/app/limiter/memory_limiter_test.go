@@ -1,7 +1,10 @@
 package limiter
 
 import (
+	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/ankardo/Rate-Limiter/internal/domain"
 )
@@ -42,7 +45,7 @@ func TestMemoryRateLimiter(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			for i := 0; i < test.requests; i++ {
-				allowed, _ := rateLimiter.AllowRequest(test.key, test.isToken)
+				allowed, _ := rateLimiter.AllowRequest(context.Background(), test.key, test.isToken)
 				if allowed != test.expectStatus[i] {
 					t.Fatalf("Test %s: Request %d: expected %v, got %v", test.name, i+1, test.expectStatus[i], allowed)
 				}
@@ -50,3 +53,281 @@ func TestMemoryRateLimiter(t *testing.T) {
 		})
 	}
 }
+
+func TestMemoryRateLimiter_Evaluate(t *testing.T) {
+	rateLimiter := NewMemoryRateLimiter(domain.LimiterConfig{
+		MaxRequests:      3,
+		TokenMaxRequests: 10,
+	})
+
+	for i := 0; i < 3; i++ {
+		decision, err := rateLimiter.Evaluate(context.Background(), "192.168.1.1", false)
+		if err != nil {
+			t.Fatalf("Request %d: unexpected error: %v", i+1, err)
+		}
+		if !decision.Allowed || decision.Remaining != int64(2-i) || decision.Limit != 3 {
+			t.Fatalf("Request %d: unexpected decision %+v", i+1, decision)
+		}
+		if decision.Policy != domain.PolicyIP || decision.Reason != domain.ReasonAllowed {
+			t.Fatalf("Request %d: unexpected policy/reason %s/%s", i+1, decision.Policy, decision.Reason)
+		}
+	}
+
+	decision, _ := rateLimiter.Evaluate(context.Background(), "192.168.1.1", false)
+	if decision.Allowed || decision.Reason != domain.ReasonLimitExceeded {
+		t.Fatalf("Expected the limit to be exceeded, got %+v", decision)
+	}
+	if decision.RetryAfter <= 0 || decision.RetryAfter > time.Second {
+		t.Fatalf("Expected a retry-after within the window, got %v", decision.RetryAfter)
+	}
+	if decision.ResetAt.Before(time.Now()) {
+		t.Fatalf("Expected the reset time to be in the future, got %v", decision.ResetAt)
+	}
+}
+
+func TestMemoryRateLimiter_BlockLifecycle(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rateLimiter := NewMemoryRateLimiter(domain.LimiterConfig{
+		MaxRequests:      2,
+		TokenMaxRequests: 3,
+		BlockDuration:    10,
+	})
+	rateLimiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if allowed, _ := rateLimiter.AllowRequest(context.Background(), "192.168.1.1", false); !allowed {
+			t.Fatalf("Request %d should have been allowed", i+1)
+		}
+	}
+
+	decision, _ := rateLimiter.Evaluate(context.Background(), "192.168.1.1", false)
+	if decision.Allowed || decision.Reason != domain.ReasonLimitExceeded || decision.RetryAfter != 10*time.Second {
+		t.Fatalf("Exceeding the limit should block the key for the block duration, got %+v", decision)
+	}
+
+	blockedUntil, blocked := rateLimiter.BlockedUntil("ip:192.168.1.1")
+	if !blocked || !blockedUntil.Equal(now.Add(10*time.Second)) {
+		t.Fatalf("Expected the key to be blocked until %v, got %v (%v)", now.Add(10*time.Second), blockedUntil, blocked)
+	}
+
+	now = now.Add(5 * time.Second)
+	decision, _ = rateLimiter.Evaluate(context.Background(), "192.168.1.1", false)
+	if decision.Allowed || decision.Reason != domain.ReasonBlocked || decision.RetryAfter != 5*time.Second {
+		t.Fatalf("Request should be blocked even though the window has passed, got %+v", decision)
+	}
+
+	if allowed, _ := rateLimiter.AllowRequest(context.Background(), "192.168.1.1", true); !allowed {
+		t.Fatalf("Blocking an IP should not block a token with the same value")
+	}
+
+	if err := rateLimiter.BlockKey(context.Background(), "ip:192.168.1.1", 1); err != nil {
+		t.Fatalf("Failed to block key: %v", err)
+	}
+	if blockedUntil, _ := rateLimiter.BlockedUntil("ip:192.168.1.1"); !blockedUntil.Equal(now.Add(5 * time.Second)) {
+		t.Fatalf("A shorter block should not shorten the existing one, got %v", blockedUntil)
+	}
+
+	now = now.Add(5 * time.Second)
+	if allowed, _ := rateLimiter.AllowRequest(context.Background(), "192.168.1.1", false); !allowed {
+		t.Fatalf("Request should be allowed once the block expires")
+	}
+	if _, exists := rateLimiter.store.shardFor("ip:192.168.1.1").limits["ip:192.168.1.1"]; exists {
+		t.Fatalf("Expired blocks should be removed")
+	}
+}
+
+func TestMemoryRateLimiter_ListAndLiftBlocks(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rateLimiter := NewMemoryRateLimiter(domain.LimiterConfig{
+		MaxRequests:   1,
+		BlockDuration: 10,
+	})
+	rateLimiter.now = func() time.Time { return now }
+
+	rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false)
+	rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false)
+	if err := rateLimiter.BlockKey(context.Background(), "token:abc", 30); err != nil {
+		t.Fatalf("Failed to block key: %v", err)
+	}
+
+	blocks, err := rateLimiter.ListBlocks(context.Background())
+	if err != nil || len(blocks) != 2 {
+		t.Fatalf("Expected 2 blocks, got %+v (%v)", blocks, err)
+	}
+	reasons := map[string]domain.Block{}
+	for _, block := range blocks {
+		reasons[block.Key] = block
+	}
+	if reasons["ip:10.0.0.1"].Reason != domain.ReasonLimitExceeded || reasons["ip:10.0.0.1"].ExpiresIn != 10*time.Second {
+		t.Fatalf("Unexpected IP block: %+v", reasons["ip:10.0.0.1"])
+	}
+	if reasons["token:abc"].Reason != domain.BlockReasonManual || reasons["token:abc"].ExpiresIn != 30*time.Second {
+		t.Fatalf("Unexpected token block: %+v", reasons["token:abc"])
+	}
+
+	if err := rateLimiter.LiftBlock(context.Background(), "ip:10.0.0.1"); err != nil {
+		t.Fatalf("Failed to lift block: %v", err)
+	}
+	if allowed, _ := rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false); allowed {
+		t.Fatalf("Lifting the block should not reset the window")
+	}
+	now = now.Add(time.Second)
+	rateLimiter.LiftBlock(context.Background(), "ip:10.0.0.1")
+	if allowed, _ := rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false); !allowed {
+		t.Fatalf("Request should be allowed once the block is lifted and the window passed")
+	}
+}
+
+func TestMemoryRateLimiter_SweepRemovesIdleKeys(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rateLimiter := NewMemoryRateLimiter(domain.LimiterConfig{
+		MaxRequests:   1,
+		BlockDuration: 5,
+	})
+	rateLimiter.now = func() time.Time { return now }
+
+	rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false)
+	rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false)
+	rateLimiter.AllowRequest(context.Background(), "10.0.0.2", false)
+
+	now = now.Add(500 * time.Millisecond)
+	rateLimiter.AllowRequest(context.Background(), "10.0.0.3", false)
+
+	now = now.Add(600 * time.Millisecond)
+	rateLimiter.sweep()
+	if stats := rateLimiter.Stats(); stats.Keys != 1 || stats.Blocks != 1 || stats.Sweeps != 1 {
+		t.Fatalf("Expected only the recent key and the block to remain, got %+v", stats)
+	}
+
+	now = now.Add(5 * time.Second)
+	rateLimiter.sweep()
+	if stats := rateLimiter.Stats(); stats.Keys != 0 || stats.Blocks != 0 || stats.ApproxBytes != 0 {
+		t.Fatalf("Expected everything to be swept, got %+v", stats)
+	}
+}
+
+func TestMemoryRateLimiter_MaxKeysEvictsLeastRecentlyUsed(t *testing.T) {
+	rateLimiter := newMemoryRateLimiter(domain.LimiterConfig{
+		MaxRequests: 2,
+		MaxKeys:     2,
+	}, 1)
+
+	rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false)
+	rateLimiter.AllowRequest(context.Background(), "10.0.0.2", false)
+	rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false)
+	rateLimiter.AllowRequest(context.Background(), "10.0.0.3", false)
+
+	stats := rateLimiter.Stats()
+	if stats.Keys != 2 || stats.Evictions != 1 {
+		t.Fatalf("Expected 2 keys and 1 eviction, got %+v", stats)
+	}
+	if _, exists := rateLimiter.store.shardFor("ip:10.0.0.2").entries["ip:10.0.0.2"]; exists {
+		t.Fatalf("Expected the least recently used key to be evicted")
+	}
+	if allowed, _ := rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false); allowed {
+		t.Fatalf("Recently used key should keep its history")
+	}
+}
+
+func TestMemoryRateLimiter_Close(t *testing.T) {
+	rateLimiter := NewMemoryRateLimiter(domain.LimiterConfig{
+		MaxRequests:     1,
+		CleanupInterval: 1,
+	})
+	rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false)
+
+	deadline := time.Now().Add(3 * time.Second)
+	for rateLimiter.Stats().Sweeps == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Janitor did not run")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if stats := rateLimiter.Stats(); stats.Keys != 0 {
+		t.Fatalf("Janitor should have removed the idle key, got %+v", stats)
+	}
+
+	if err := rateLimiter.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := rateLimiter.Close(); err != nil {
+		t.Fatalf("Close should be idempotent: %v", err)
+	}
+}
+
+func TestMemoryRateLimiter_ShardsBoundKeys(t *testing.T) {
+	rateLimiter := newMemoryRateLimiter(domain.LimiterConfig{
+		MaxRequests: 1,
+		MaxKeys:     64,
+	}, 8)
+
+	for i := 0; i < 1000; i++ {
+		rateLimiter.AllowRequest(context.Background(), fmt.Sprintf("10.0.%d.%d", i/256, i%256), false)
+	}
+
+	stats := rateLimiter.Stats()
+	if stats.Keys != 64 {
+		t.Fatalf("Expected the key count to stay bounded at 64, got %+v", stats)
+	}
+	if stats.Evictions != int64(1000-stats.Keys) {
+		t.Fatalf("Expected every dropped key to be counted as an eviction, got %+v", stats)
+	}
+}
+
+func TestMemoryRateLimiter_CollidingKeysDoNotEvictBelowMaxKeys(t *testing.T) {
+	rateLimiter := newMemoryRateLimiter(domain.LimiterConfig{
+		MaxRequests: 1,
+		MaxKeys:     64,
+	}, 8)
+
+	hot := rateLimiter.store.shards[0]
+	var colliding []string
+	for i := 0; len(colliding) < 32; i++ {
+		key := fmt.Sprintf("10.1.%d.%d", i/256, i%256)
+		if rateLimiter.store.shardFor("ip:"+key) == hot {
+			colliding = append(colliding, key)
+		}
+	}
+	for _, key := range colliding {
+		rateLimiter.AllowRequest(context.Background(), key, false)
+	}
+
+	if stats := rateLimiter.Stats(); stats.Keys != 32 || stats.Evictions != 0 {
+		t.Fatalf("Expected keys sharing a shard to be kept below MaxKeys, got %+v", stats)
+	}
+	for _, key := range colliding {
+		if allowed, _ := rateLimiter.AllowRequest(context.Background(), key, false); allowed {
+			t.Fatalf("Expected %s to keep its history", key)
+		}
+	}
+}
+
+func TestMemoryRateLimiter_ConfigurableWindow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rateLimiter := NewMemoryRateLimiter(domain.LimiterConfig{
+		MaxRequests:      2,
+		TokenMaxRequests: 3,
+		Window:           time.Minute,
+		TokenWindow:      time.Hour,
+	})
+	rateLimiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false)
+	}
+	now = now.Add(30 * time.Second)
+	decision, _ := rateLimiter.Evaluate(context.Background(), "10.0.0.1", false)
+	if decision.Allowed || decision.Window != time.Minute || decision.RetryAfter != 30*time.Second {
+		t.Fatalf("Expected the minute window to still be full, got %+v", decision)
+	}
+
+	now = now.Add(30 * time.Second)
+	if allowed, _ := rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false); !allowed {
+		t.Fatalf("Request should be allowed once the minute has passed")
+	}
+
+	decision, _ = rateLimiter.Evaluate(context.Background(), "abc", true)
+	if decision.Window != time.Hour || decision.Remaining != 2 {
+		t.Fatalf("Expected the token window to be used for tokens, got %+v", decision)
+	}
+}
@@ -0,0 +1,136 @@
+package limiter
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+type windowCounter struct {
+	index        int64
+	current      int
+	previous     int
+	blockedUntil time.Time
+}
+
+type MemorySlidingWindowLimiter struct {
+	store  *memoryStore[*windowCounter]
+	config domain.LimiterConfig
+	now    func() time.Time
+}
+
+func NewMemorySlidingWindowLimiter(config domain.LimiterConfig) *MemorySlidingWindowLimiter {
+	m := &MemorySlidingWindowLimiter{
+		store:  newMemoryStore[*windowCounter](config.MaxKeys, defaultMemoryShards),
+		config: config,
+		now:    clockFor(config),
+	}
+	m.store.runJanitor(time.Duration(config.CleanupInterval)*time.Second, m.sweep)
+	return m
+}
+
+func (m *MemorySlidingWindowLimiter) AllowRequest(ctx context.Context, key string, isToken bool) (bool, error) {
+	decision, err := m.Evaluate(ctx, key, isToken)
+	return decision.Allowed, err
+}
+
+func (m *MemorySlidingWindowLimiter) Evaluate(ctx context.Context, key string, isToken bool) (domain.Decision, error) {
+	return m.EvaluateCost(ctx, key, isToken, 1)
+}
+
+func (m *MemorySlidingWindowLimiter) EvaluateCost(ctx context.Context, key string, isToken bool, cost int64) (domain.Decision, error) {
+	prefixedKey := prefixKey(key, isToken)
+	shard := m.store.shardFor(prefixedKey)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	limit := limitFor(m.config, isToken)
+	window := windowFor(m.config, isToken)
+	now := m.now()
+	if decision, oversized := oversizedDecision(cost, int64(limit), window, isToken, now); oversized {
+		return decision, nil
+	}
+	index := now.UnixNano() / int64(window)
+
+	counter := m.store.entry(shard, prefixedKey, func() *windowCounter {
+		return &windowCounter{index: index}
+	})
+
+	if now.Before(counter.blockedUntil) {
+		blockedFor := counter.blockedUntil.Sub(now)
+		result := domain.StoreResult{Blocked: true, RetryAfter: blockedFor, ResetAfter: blockedFor}
+		return decisionFromResult(result, int64(limit), window, isToken, now), nil
+	}
+
+	switch {
+	case index == counter.index+1:
+		counter.previous, counter.current = counter.current, 0
+	case index > counter.index+1:
+		counter.previous, counter.current = 0, 0
+	}
+	counter.index = index
+
+	windowStart := time.Unix(0, index*int64(window))
+	windowEnd := windowStart.Add(window)
+	elapsed := float64(now.Sub(windowStart)) / float64(window)
+	estimate := float64(counter.previous)*(1-elapsed) + float64(counter.current)
+	threshold := limit - int(cost) + 1
+
+	if estimate >= float64(threshold) {
+		result := domain.StoreResult{ResetAfter: windowEnd.Sub(now)}
+		if m.config.BlockDuration > 0 {
+			result.RetryAfter = time.Duration(m.config.BlockDuration) * time.Second
+			result.ResetAfter = result.RetryAfter
+			counter.blockedUntil = now.Add(result.RetryAfter)
+		} else if threshold <= 0 {
+			result.RetryAfter = windowEnd.Add(window).Sub(now)
+		} else if counter.current < threshold {
+			fraction := 1 - float64(threshold-counter.current)/float64(counter.previous)
+			result.RetryAfter = windowStart.Add(time.Duration(fraction*float64(window))).Sub(now) + time.Microsecond
+		} else {
+			fraction := math.Max(0, 1-float64(threshold)/float64(counter.current))
+			result.RetryAfter = windowEnd.Add(time.Duration(fraction*float64(window))).Sub(now) + time.Microsecond
+		}
+		return decisionFromResult(result, int64(limit), window, isToken, now), nil
+	}
+
+	counter.current += int(cost)
+	result := domain.StoreResult{
+		Allowed:    true,
+		Remaining:  int64(math.Max(0, math.Floor(float64(limit)-estimate-float64(cost)))),
+		ResetAfter: windowEnd.Sub(now),
+	}
+	return decisionFromResult(result, int64(limit), window, isToken, now), nil
+}
+
+func (m *MemorySlidingWindowLimiter) BlockKey(ctx context.Context, key string, duration int64) error {
+	shard := m.store.shardFor(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	now := m.now()
+	counter := m.store.entry(shard, key, func() *windowCounter {
+		return &windowCounter{index: now.UnixNano() / int64(windowFor(m.config, isTokenKey(key)))}
+	})
+	counter.blockedUntil = now.Add(time.Duration(duration) * time.Second)
+	return nil
+}
+
+func (m *MemorySlidingWindowLimiter) Stats() MemoryStats {
+	return m.store.stats()
+}
+
+func (m *MemorySlidingWindowLimiter) Close() error {
+	return m.store.Close()
+}
+
+// sweep drops counters whose current and previous windows have both passed.
+func (m *MemorySlidingWindowLimiter) sweep() {
+	now := m.now()
+	m.store.sweep(now, func(key string, counter *windowCounter) bool {
+		index := now.UnixNano() / int64(windowFor(m.config, isTokenKey(key)))
+		return index > counter.index+1 && !now.Before(counter.blockedUntil)
+	})
+}
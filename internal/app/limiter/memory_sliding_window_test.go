@@ -0,0 +1,56 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func TestMemorySlidingWindowLimiter(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rateLimiter := NewMemorySlidingWindowLimiter(domain.LimiterConfig{
+		MaxRequests:      4,
+		TokenMaxRequests: 10,
+	})
+	rateLimiter.now = func() time.Time { return now }
+
+	tests := []struct {
+		name         string
+		advance      time.Duration
+		expectStatus []bool
+	}{
+		{
+			name:         "Fill the current window",
+			expectStatus: []bool{true, true, true, true, false},
+		},
+		{
+			name:         "Previous window weighted at 75%",
+			advance:      1250 * time.Millisecond,
+			expectStatus: []bool{true, false},
+		},
+		{
+			name:         "Previous window weighted at 25%",
+			advance:      500 * time.Millisecond,
+			expectStatus: []bool{true, true, false},
+		},
+		{
+			name:         "Idle for more than a window",
+			advance:      3 * time.Second,
+			expectStatus: []bool{true, true, true, true, false},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			now = now.Add(test.advance)
+			for i, expected := range test.expectStatus {
+				allowed, _ := rateLimiter.AllowRequest(context.Background(), "192.168.1.1", false)
+				if allowed != expected {
+					t.Fatalf("Test %s: Request %d: expected %v, got %v", test.name, i+1, expected, allowed)
+				}
+			}
+		})
+	}
+}
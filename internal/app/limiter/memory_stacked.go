@@ -0,0 +1,141 @@
+package limiter
+
+import (
+	"context"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+type fixedWindow struct {
+	count   int64
+	resetAt time.Time
+}
+
+type stackedCounters struct {
+	windows      []fixedWindow
+	blockedUntil time.Time
+}
+
+type MemoryStackedLimiter struct {
+	store  *memoryStore[*stackedCounters]
+	config domain.LimiterConfig
+	now    func() time.Time
+}
+
+func NewMemoryStackedLimiter(config domain.LimiterConfig) *MemoryStackedLimiter {
+	m := &MemoryStackedLimiter{
+		store:  newMemoryStore[*stackedCounters](config.MaxKeys, defaultMemoryShards),
+		config: config,
+		now:    clockFor(config),
+	}
+	m.store.runJanitor(time.Duration(config.CleanupInterval)*time.Second, m.sweep)
+	return m
+}
+
+func (m *MemoryStackedLimiter) AllowRequest(ctx context.Context, key string, isToken bool) (bool, error) {
+	decision, err := m.Evaluate(ctx, key, isToken)
+	return decision.Allowed, err
+}
+
+func (m *MemoryStackedLimiter) Evaluate(ctx context.Context, key string, isToken bool) (domain.Decision, error) {
+	return m.EvaluateCost(ctx, key, isToken, 1)
+}
+
+func (m *MemoryStackedLimiter) EvaluateCost(ctx context.Context, key string, isToken bool, cost int64) (domain.Decision, error) {
+	prefixedKey := prefixKey(key, isToken)
+	rules := rulesFor(m.config, isToken)
+	now := m.now()
+	if result, oversized := oversizedStacked(cost, rules); oversized {
+		return stackedDecision(result, rules, isToken, now), nil
+	}
+	shard := m.store.shardFor(prefixedKey)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	counters := m.countersFor(shard, prefixedKey, len(rules))
+
+	result := domain.StackedResult{Allowed: true, Tripped: -1, Limits: make([]domain.LimitResult, len(rules))}
+	if now.Before(counters.blockedUntil) {
+		result.Allowed = false
+		result.Blocked = true
+		result.RetryAfter = counters.blockedUntil.Sub(now)
+	}
+
+	for i, rule := range rules {
+		window := &counters.windows[i]
+		if !now.Before(window.resetAt) {
+			window.count = 0
+			window.resetAt = now.Add(rule.Window)
+		}
+		if result.Allowed && window.count+cost > rule.MaxRequests {
+			if result.Tripped < 0 || window.resetAt.After(counters.windows[result.Tripped].resetAt) {
+				result.Tripped = i
+			}
+		}
+	}
+
+	if result.Tripped >= 0 {
+		result.Allowed = false
+		result.RetryAfter = counters.windows[result.Tripped].resetAt.Sub(now)
+		if m.config.BlockDuration > 0 {
+			result.RetryAfter = time.Duration(m.config.BlockDuration) * time.Second
+			counters.blockedUntil = now.Add(result.RetryAfter)
+		}
+	}
+
+	for i, rule := range rules {
+		window := &counters.windows[i]
+		if result.Allowed {
+			window.count += cost
+		}
+		result.Limits[i] = domain.LimitResult{
+			Remaining:  max(0, rule.MaxRequests-window.count),
+			ResetAfter: window.resetAt.Sub(now),
+		}
+	}
+
+	return stackedDecision(result, rules, isToken, now), nil
+}
+
+func (m *MemoryStackedLimiter) BlockKey(ctx context.Context, key string, duration int64) error {
+	shard := m.store.shardFor(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	counters := m.countersFor(shard, key, len(rulesFor(m.config, isTokenKey(key))))
+	blockedUntil := m.now().Add(time.Duration(duration) * time.Second)
+	if blockedUntil.After(counters.blockedUntil) {
+		counters.blockedUntil = blockedUntil
+	}
+	return nil
+}
+
+func (m *MemoryStackedLimiter) Stats() MemoryStats {
+	return m.store.stats()
+}
+
+func (m *MemoryStackedLimiter) Close() error {
+	return m.store.Close()
+}
+
+// sweep drops keys whose windows have all reset and that are not blocked.
+func (m *MemoryStackedLimiter) sweep() {
+	now := m.now()
+	m.store.sweep(now, func(key string, counters *stackedCounters) bool {
+		if now.Before(counters.blockedUntil) {
+			return false
+		}
+		for _, window := range counters.windows {
+			if now.Before(window.resetAt) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func (m *MemoryStackedLimiter) countersFor(shard *memoryShard[*stackedCounters], key string, rules int) *stackedCounters {
+	return m.store.entry(shard, key, func() *stackedCounters {
+		return &stackedCounters{windows: make([]fixedWindow, rules)}
+	})
+}
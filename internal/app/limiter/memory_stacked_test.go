@@ -0,0 +1,86 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func TestMemoryStackedLimiter_Evaluate(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rateLimiter := NewMemoryStackedLimiter(domain.LimiterConfig{
+		TokenMaxRequests: 100,
+		Limits: []domain.LimitRule{
+			{MaxRequests: 2, Window: time.Second},
+			{MaxRequests: 3, Window: time.Minute},
+		},
+	})
+	rateLimiter.now = func() time.Time { return now }
+
+	decision, _ := rateLimiter.Evaluate(context.Background(), "10.0.0.1", false)
+	if !decision.Allowed || decision.Policy != "ip-1s" || decision.Remaining != 1 || len(decision.Limits) != 2 {
+		t.Fatalf("Expected the per-second limit to be the most restrictive, got %+v", decision)
+	}
+	rateLimiter.Evaluate(context.Background(), "10.0.0.1", false)
+
+	decision, _ = rateLimiter.Evaluate(context.Background(), "10.0.0.1", false)
+	if decision.Allowed || decision.Policy != "ip-1s" || decision.RetryAfter != time.Second {
+		t.Fatalf("Expected the per-second limit to trip, got %+v", decision)
+	}
+	if decision.Limits[1].Remaining != 1 {
+		t.Fatalf("Denied requests should not count against other limits, got %+v", decision.Limits[1])
+	}
+
+	now = now.Add(time.Second)
+	decision, _ = rateLimiter.Evaluate(context.Background(), "10.0.0.1", false)
+	if !decision.Allowed || decision.Policy != "ip-1m" || decision.Remaining != 0 {
+		t.Fatalf("Expected the per-minute limit to be the most restrictive, got %+v", decision)
+	}
+
+	decision, _ = rateLimiter.Evaluate(context.Background(), "10.0.0.1", false)
+	if decision.Allowed || decision.Policy != "ip-1m" || decision.Reason != domain.ReasonLimitExceeded || decision.RetryAfter != 59*time.Second {
+		t.Fatalf("Expected the per-minute limit to trip, got %+v", decision)
+	}
+	if decision.Limits[0].Remaining != 1 {
+		t.Fatalf("Denied requests should not count against the per-second limit, got %+v", decision.Limits[0])
+	}
+
+	decision, _ = rateLimiter.Evaluate(context.Background(), "abc", true)
+	if !decision.Allowed || decision.Policy != "token-1s" || len(decision.Limits) != 1 {
+		t.Fatalf("Tokens without stacked limits should use a single rule, got %+v", decision)
+	}
+}
+
+func TestMemoryStackedLimiter_Block(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rateLimiter := NewMemoryStackedLimiter(domain.LimiterConfig{
+		BlockDuration: 10,
+		Limits: []domain.LimitRule{
+			{MaxRequests: 1, Window: time.Second},
+			{MaxRequests: 5, Window: time.Hour},
+		},
+	})
+	rateLimiter.now = func() time.Time { return now }
+
+	rateLimiter.Evaluate(context.Background(), "10.0.0.1", false)
+	decision, _ := rateLimiter.Evaluate(context.Background(), "10.0.0.1", false)
+	if decision.Allowed || decision.RetryAfter != 10*time.Second {
+		t.Fatalf("Expected tripping a limit to block for the block duration, got %+v", decision)
+	}
+
+	now = now.Add(5 * time.Second)
+	decision, _ = rateLimiter.Evaluate(context.Background(), "10.0.0.1", false)
+	if decision.Allowed || decision.Reason != domain.ReasonBlocked || decision.RetryAfter != 5*time.Second {
+		t.Fatalf("Expected the key to stay blocked, got %+v", decision)
+	}
+
+	if err := rateLimiter.BlockKey(context.Background(), "ip:10.0.0.1", 1); err != nil {
+		t.Fatalf("Failed to block key: %v", err)
+	}
+	now = now.Add(5 * time.Second)
+	if allowed, _ := rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false); !allowed {
+		t.Fatalf("A shorter manual block should not extend the existing one")
+	}
+}
@@ -0,0 +1,282 @@
+package limiter
+
+import (
+	"container/list"
+	"errors"
+	"hash/fnv"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+const defaultMemoryShards = 64
+
+var ErrMemoryStoreFull = errors.New("memory store is full")
+
+type memoryBlock struct {
+	until  time.Time
+	reason string
+}
+
+type memoryEntry[T any] struct {
+	key   string
+	value T
+}
+
+type memoryShard[T any] struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	limits  map[string]memoryBlock
+}
+
+// memoryStore keeps per-key limiter state in hash-sharded maps so unrelated
+// keys do not contend on one lock. MaxKeys bounds the whole store rather than
+// each shard, counting blocks alongside limiter state: a new key only evicts
+// once the store is full, taking the least recently used key of its own shard,
+// or of another shard when its own is empty. Blocks are only evicted, soonest
+// expiring first, once no limiter state is left to drop.
+type memoryStore[T any] struct {
+	shards    []*memoryShard[T]
+	maxKeys   int64
+	keys      atomic.Int64
+	evictions atomic.Int64
+	sweeps    atomic.Int64
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func clockFor(config domain.LimiterConfig) func() time.Time {
+	if config.Clock != nil {
+		return config.Clock
+	}
+	return time.Now
+}
+
+func newMemoryStore[T any](maxKeys, shardCount int) *memoryStore[T] {
+	if maxKeys > 0 && maxKeys < shardCount {
+		shardCount = maxKeys
+	}
+	s := &memoryStore[T]{
+		shards:  make([]*memoryShard[T], shardCount),
+		maxKeys: int64(maxKeys),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for i := range s.shards {
+		s.shards[i] = &memoryShard[T]{
+			entries: make(map[string]*list.Element),
+			lru:     list.New(),
+			limits:  make(map[string]memoryBlock),
+		}
+	}
+	return s
+}
+
+func (s *memoryStore[T]) shardFor(key string) *memoryShard[T] {
+	if len(s.shards) == 1 {
+		return s.shards[0]
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return s.shards[hash.Sum32()%uint32(len(s.shards))]
+}
+
+// entry returns the state of key, creating it when missing. When the store is
+// full and nothing can be evicted, the new state is returned without being
+// kept. The caller must hold the shard lock.
+func (s *memoryStore[T]) entry(shard *memoryShard[T], key string, create func() T) T {
+	if element, exists := shard.entries[key]; exists {
+		shard.lru.MoveToFront(element)
+		return element.Value.(*memoryEntry[T]).value
+	}
+
+	entry := &memoryEntry[T]{key: key, value: create()}
+	if s.reserve(shard) {
+		shard.entries[key] = shard.lru.PushFront(entry)
+	}
+	return entry.value
+}
+
+// reserve counts a new key against MaxKeys, evicting when the store is full.
+// It reports false, leaving the count unchanged, when nothing could be
+// evicted. The caller must hold the shard lock.
+func (s *memoryStore[T]) reserve(shard *memoryShard[T]) bool {
+	if keys := s.keys.Add(1); s.maxKeys > 0 && keys > s.maxKeys && !s.evict(shard) {
+		s.keys.Add(-1)
+		return false
+	}
+	return true
+}
+
+// block blocks key until now+duration, keeping a longer existing block. It
+// reports false when the store is full and the block could not be kept. The
+// caller must hold the shard lock.
+func (s *memoryStore[T]) block(shard *memoryShard[T], key, reason string, now time.Time, duration time.Duration) (time.Time, bool) {
+	blockedUntil := now.Add(duration)
+	current, exists := shard.limits[key]
+	if exists && current.until.After(blockedUntil) {
+		return current.until, true
+	}
+	if !exists && !s.reserve(shard) {
+		return blockedUntil, false
+	}
+	shard.limits[key] = memoryBlock{until: blockedUntil, reason: reason}
+	return blockedUntil, true
+}
+
+// blockedUntil reports whether key is blocked, dropping an expired block. The
+// caller must hold the shard lock.
+func (s *memoryStore[T]) blockedUntil(shard *memoryShard[T], key string, now time.Time) (time.Time, bool) {
+	entry, exists := shard.limits[key]
+	if !exists {
+		return time.Time{}, false
+	}
+	if !now.Before(entry.until) {
+		s.unblock(shard, key)
+		return time.Time{}, false
+	}
+	return entry.until, true
+}
+
+// unblock lifts the block on key. The caller must hold the shard lock.
+func (s *memoryStore[T]) unblock(shard *memoryShard[T], key string) {
+	if _, exists := shard.limits[key]; exists {
+		delete(shard.limits, key)
+		s.keys.Add(-1)
+	}
+}
+
+func (s *memoryStore[T]) evict(shard *memoryShard[T]) bool {
+	return s.evictFrom(shard, (*memoryShard[T]).evictOldest) || s.evictFrom(shard, (*memoryShard[T]).evictBlock)
+}
+
+// evictFrom drops one key with drop, trying shard first, then every other
+// shard whose lock is free, and finally waiting on the shards after shard in
+// order. Only ever waiting on a later shard while holding an earlier one keeps
+// concurrent evictions from deadlocking.
+func (s *memoryStore[T]) evictFrom(shard *memoryShard[T], drop func(*memoryShard[T]) bool) bool {
+	evicted := drop(shard)
+	for _, other := range s.shards {
+		if evicted {
+			break
+		}
+		if other == shard || !other.mu.TryLock() {
+			continue
+		}
+		evicted = drop(other)
+		other.mu.Unlock()
+	}
+	for _, other := range s.shards[slices.Index(s.shards, shard)+1:] {
+		if evicted {
+			break
+		}
+		other.mu.Lock()
+		evicted = drop(other)
+		other.mu.Unlock()
+	}
+	if evicted {
+		s.keys.Add(-1)
+		s.evictions.Add(1)
+	}
+	return evicted
+}
+
+// sweep drops every key idle reports as holding no state, and expired blocks.
+func (s *memoryStore[T]) sweep(now time.Time, idle func(key string, value T) bool) {
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		for element := shard.lru.Back(); element != nil; {
+			previous := element.Prev()
+			entry := element.Value.(*memoryEntry[T])
+			if idle(entry.key, entry.value) {
+				shard.lru.Remove(element)
+				delete(shard.entries, entry.key)
+				s.keys.Add(-1)
+			}
+			element = previous
+		}
+		for key := range shard.limits {
+			s.blockedUntil(shard, key, now)
+		}
+		shard.mu.Unlock()
+	}
+	s.sweeps.Add(1)
+}
+
+// stats reports the counters every strategy shares; the sliding log adds its
+// timestamp and memory estimates on top.
+func (s *memoryStore[T]) stats() MemoryStats {
+	stats := MemoryStats{
+		Evictions: s.evictions.Load(),
+		Sweeps:    s.sweeps.Load(),
+	}
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		stats.Keys += len(shard.entries)
+		stats.Blocks += len(shard.limits)
+		shard.mu.Unlock()
+	}
+	return stats
+}
+
+// runJanitor calls sweep every interval until Close. A zero interval disables
+// background cleanup.
+func (s *memoryStore[T]) runJanitor(interval time.Duration, sweep func()) {
+	if interval <= 0 {
+		close(s.done)
+		return
+	}
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				sweep()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (s *memoryStore[T]) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+	return nil
+}
+
+func (s *memoryShard[T]) evictOldest() bool {
+	oldest := s.lru.Back()
+	if oldest == nil {
+		return false
+	}
+	s.lru.Remove(oldest)
+	delete(s.entries, oldest.Value.(*memoryEntry[T]).key)
+	return true
+}
+
+// evictBlock drops the block that expires first.
+func (s *memoryShard[T]) evictBlock() bool {
+	var soonest string
+	var until time.Time
+	for key, block := range s.limits {
+		if until.IsZero() || block.until.Before(until) {
+			soonest, until = key, block.until
+		}
+	}
+	if until.IsZero() {
+		return false
+	}
+	delete(s.limits, soonest)
+	return true
+}
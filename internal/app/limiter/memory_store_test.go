@@ -0,0 +1,154 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+type sweptLimiter interface {
+	domain.Limiter
+	Stats() MemoryStats
+	sweep()
+}
+
+func TestMemoryLimiters_SweepAndMaxKeys(t *testing.T) {
+	config := domain.LimiterConfig{
+		MaxRequests:   2,
+		Window:        time.Second,
+		BurstCapacity: 2,
+		Limits:        []domain.LimitRule{{MaxRequests: 2, Window: time.Second}},
+		MaxKeys:       16,
+	}
+
+	tests := []struct {
+		name  string
+		build func(now func() time.Time) sweptLimiter
+	}{
+		{name: "token bucket", build: func(now func() time.Time) sweptLimiter {
+			l := NewMemoryTokenBucketLimiter(config)
+			l.now = now
+			return l
+		}},
+		{name: "gcra", build: func(now func() time.Time) sweptLimiter {
+			l := NewMemoryGCRALimiter(config)
+			l.now = now
+			return l
+		}},
+		{name: "sliding window", build: func(now func() time.Time) sweptLimiter {
+			l := NewMemorySlidingWindowLimiter(config)
+			l.now = now
+			return l
+		}},
+		{name: "stacked", build: func(now func() time.Time) sweptLimiter {
+			l := NewMemoryStackedLimiter(config)
+			l.now = now
+			return l
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Unix(1700000000, 0)
+			rateLimiter := tt.build(func() time.Time { return now })
+
+			for i := 0; i < 100; i++ {
+				rateLimiter.AllowRequest(context.Background(), fmt.Sprintf("10.0.0.%d", i), false)
+			}
+			if stats := rateLimiter.Stats(); stats.Keys != 16 || stats.Evictions != 84 {
+				t.Fatalf("Expected MaxKeys to bound the store at 16 keys, got %+v", stats)
+			}
+
+			rateLimiter.AllowRequest(context.Background(), "10.0.1.1", false)
+			rateLimiter.AllowRequest(context.Background(), "10.0.1.1", false)
+			rateLimiter.sweep()
+			if stats := rateLimiter.Stats(); stats.Keys != 16 || stats.Sweeps != 1 {
+				t.Fatalf("Expected keys with live state to survive a sweep, got %+v", stats)
+			}
+
+			now = now.Add(3 * time.Second)
+			rateLimiter.sweep()
+			if stats := rateLimiter.Stats(); stats.Keys != 0 {
+				t.Fatalf("Expected idle keys to be swept, got %+v", stats)
+			}
+		})
+	}
+}
+
+func TestMemoryRateLimiter_BlocksCountAgainstMaxKeys(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rateLimiter := NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 1, Window: time.Minute, BlockDuration: 60, MaxKeys: 8})
+	rateLimiter.now = func() time.Time { return now }
+	defer rateLimiter.Close()
+
+	for i := 0; i < 4; i++ {
+		rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false)
+	}
+	for i := 0; i < 100; i++ {
+		rateLimiter.BlockKey(context.Background(), fmt.Sprintf("ip:10.0.1.%d", i), 60+int64(i))
+	}
+	stats := rateLimiter.Stats()
+	if stats.Keys != 0 || stats.Blocks != 8 || stats.Evictions != 94 {
+		t.Fatalf("Expected blocks to fill MaxKeys once limiter state is gone, got %+v", stats)
+	}
+	if _, blocked := rateLimiter.BlockedUntil("ip:10.0.1.99"); !blocked {
+		t.Fatalf("Expected the latest blocks to survive eviction")
+	}
+
+	rateLimiter.LiftBlock(context.Background(), "ip:10.0.1.99")
+	rateLimiter.AllowRequest(context.Background(), "10.0.2.1", false)
+	if stats := rateLimiter.Stats(); stats.Keys != 1 || stats.Blocks != 7 || stats.Evictions != 94 {
+		t.Fatalf("Expected a lifted block to free its slot, got %+v", stats)
+	}
+}
+
+func TestMemoryStore_EvictsFromContendedShards(t *testing.T) {
+	store := newMemoryStore[int](2, 2)
+	keysIn := func(shard int) []string {
+		var keys []string
+		for i := 0; len(keys) < 2; i++ {
+			if key := fmt.Sprintf("key-%d", i); store.shardFor(key) == store.shards[shard] {
+				keys = append(keys, key)
+			}
+		}
+		return keys
+	}
+	first, last := keysIn(0), keysIn(1)
+	insert := func(key string) {
+		shard := store.shardFor(key)
+		shard.mu.Lock()
+		defer shard.mu.Unlock()
+		store.entry(shard, key, func() int { return 0 })
+	}
+
+	insert(last[0])
+	insert(last[1])
+	store.shards[1].mu.Lock()
+	inserted := make(chan struct{})
+	go func() {
+		insert(first[0])
+		close(inserted)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	store.shards[1].mu.Unlock()
+	<-inserted
+	if stats := store.stats(); stats.Keys != 2 || stats.Evictions != 1 {
+		t.Fatalf("Expected the insert to wait for a later shard and evict from it, got %+v", stats)
+	}
+
+	store = newMemoryStore[int](2, 2)
+	insert(first[0])
+	insert(first[1])
+	store.shards[0].mu.Lock()
+	insert(last[0])
+	store.shards[0].mu.Unlock()
+	if stats := store.stats(); stats.Keys != 2 || stats.Evictions != 0 || store.keys.Load() != 2 {
+		t.Fatalf("Expected an insert with nothing to evict to be rejected, got %+v", stats)
+	}
+	if _, stored := store.shards[1].entries[last[0]]; stored {
+		t.Fatalf("Expected the rejected key not to be stored")
+	}
+}
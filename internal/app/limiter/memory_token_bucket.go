@@ -0,0 +1,117 @@
+package limiter
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+type tokenBucket struct {
+	tokens       float64
+	lastRefill   time.Time
+	blockedUntil time.Time
+}
+
+type MemoryTokenBucketLimiter struct {
+	store  *memoryStore[*tokenBucket]
+	config domain.LimiterConfig
+	now    func() time.Time
+}
+
+func NewMemoryTokenBucketLimiter(config domain.LimiterConfig) *MemoryTokenBucketLimiter {
+	m := &MemoryTokenBucketLimiter{
+		store:  newMemoryStore[*tokenBucket](config.MaxKeys, defaultMemoryShards),
+		config: config,
+		now:    clockFor(config),
+	}
+	m.store.runJanitor(time.Duration(config.CleanupInterval)*time.Second, m.sweep)
+	return m
+}
+
+func (m *MemoryTokenBucketLimiter) AllowRequest(ctx context.Context, key string, isToken bool) (bool, error) {
+	decision, err := m.Evaluate(ctx, key, isToken)
+	return decision.Allowed, err
+}
+
+func (m *MemoryTokenBucketLimiter) Evaluate(ctx context.Context, key string, isToken bool) (domain.Decision, error) {
+	return m.EvaluateCost(ctx, key, isToken, 1)
+}
+
+func (m *MemoryTokenBucketLimiter) EvaluateCost(ctx context.Context, key string, isToken bool, cost int64) (domain.Decision, error) {
+	prefixedKey := prefixKey(key, isToken)
+	shard := m.store.shardFor(prefixedKey)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	capacity := float64(burstFor(m.config, isToken))
+	refillRate := refillRateFor(m.config, isToken)
+	now := m.now()
+	if decision, oversized := oversizedDecision(cost, int64(capacity), burstWindowFor(m.config, isToken), isToken, now); oversized {
+		return decision, nil
+	}
+
+	bucket := m.store.entry(shard, prefixedKey, func() *tokenBucket {
+		return &tokenBucket{tokens: capacity, lastRefill: now}
+	})
+
+	if now.Before(bucket.blockedUntil) {
+		blockedFor := bucket.blockedUntil.Sub(now)
+		result := domain.StoreResult{Blocked: true, RetryAfter: blockedFor, ResetAfter: blockedFor}
+		return decisionFromResult(result, int64(capacity), burstWindowFor(m.config, isToken), isToken, now), nil
+	}
+
+	elapsed := now.Sub(bucket.lastRefill).Seconds()
+	bucket.tokens = math.Min(capacity, bucket.tokens+elapsed*refillRate)
+	bucket.lastRefill = now
+
+	result := domain.StoreResult{}
+	if bucket.tokens >= float64(cost) {
+		bucket.tokens -= float64(cost)
+		result.Allowed = true
+	} else if m.config.BlockDuration > 0 {
+		result.RetryAfter = time.Duration(m.config.BlockDuration) * time.Second
+		bucket.blockedUntil = now.Add(result.RetryAfter)
+	} else if refillRate > 0 {
+		result.RetryAfter = secondsToDuration((float64(cost) - bucket.tokens) / refillRate)
+	}
+
+	result.Remaining = int64(math.Floor(bucket.tokens))
+	if refillRate > 0 {
+		result.ResetAfter = secondsToDuration((capacity - bucket.tokens) / refillRate)
+	}
+	return decisionFromResult(result, int64(capacity), burstWindowFor(m.config, isToken), isToken, now), nil
+}
+
+func (m *MemoryTokenBucketLimiter) BlockKey(ctx context.Context, key string, duration int64) error {
+	shard := m.store.shardFor(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	now := m.now()
+	bucket := m.store.entry(shard, key, func() *tokenBucket {
+		return &tokenBucket{lastRefill: now}
+	})
+	bucket.blockedUntil = now.Add(time.Duration(duration) * time.Second)
+	return nil
+}
+
+func (m *MemoryTokenBucketLimiter) Stats() MemoryStats {
+	return m.store.stats()
+}
+
+func (m *MemoryTokenBucketLimiter) Close() error {
+	return m.store.Close()
+}
+
+// sweep drops buckets that have refilled completely and are not blocked, since
+// a fresh bucket holds the same state.
+func (m *MemoryTokenBucketLimiter) sweep() {
+	now := m.now()
+	m.store.sweep(now, func(key string, bucket *tokenBucket) bool {
+		isToken := isTokenKey(key)
+		refilled := bucket.tokens + now.Sub(bucket.lastRefill).Seconds()*refillRateFor(m.config, isToken)
+		return refilled >= float64(burstFor(m.config, isToken)) && !now.Before(bucket.blockedUntil)
+	})
+}
@@ -0,0 +1,98 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func TestMemoryTokenBucketLimiter(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rateLimiter := NewMemoryTokenBucketLimiter(domain.LimiterConfig{
+		MaxRequests:        2,
+		TokenMaxRequests:   4,
+		BurstCapacity:      5,
+		TokenBurstCapacity: 0,
+	})
+	rateLimiter.now = func() time.Time { return now }
+
+	tests := []struct {
+		name         string
+		key          string
+		isToken      bool
+		advance      time.Duration
+		expectStatus []bool
+	}{
+		{
+			name:         "Burst up to capacity by IP",
+			key:          "192.168.1.1",
+			expectStatus: []bool{true, true, true, true, true, false},
+		},
+		{
+			name:         "Refill at the configured rate by IP",
+			key:          "192.168.1.1",
+			advance:      time.Second,
+			expectStatus: []bool{true, true, false},
+		},
+		{
+			name:         "Capacity defaults to the limit by Token",
+			key:          "valid-token",
+			isToken:      true,
+			expectStatus: []bool{true, true, true, true, false},
+		},
+		{
+			name:         "Refill never exceeds capacity",
+			key:          "valid-token",
+			isToken:      true,
+			advance:      time.Minute,
+			expectStatus: []bool{true, true, true, true, false},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			now = now.Add(test.advance)
+			for i, expected := range test.expectStatus {
+				allowed, _ := rateLimiter.AllowRequest(context.Background(), test.key, test.isToken)
+				if allowed != expected {
+					t.Fatalf("Test %s: Request %d: expected %v, got %v", test.name, i+1, expected, allowed)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryTokenBucketLimiter_Block(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rateLimiter := NewMemoryTokenBucketLimiter(domain.LimiterConfig{
+		MaxRequests:   1,
+		BlockDuration: 10,
+	})
+	rateLimiter.now = func() time.Time { return now }
+
+	if allowed, _ := rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false); !allowed {
+		t.Fatalf("First request should have been allowed")
+	}
+	if allowed, _ := rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false); allowed {
+		t.Fatalf("Second request should have been denied")
+	}
+
+	now = now.Add(5 * time.Second)
+	if allowed, _ := rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false); allowed {
+		t.Fatalf("Request should be blocked until the block duration elapses")
+	}
+
+	now = now.Add(6 * time.Second)
+	if allowed, _ := rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false); !allowed {
+		t.Fatalf("Request should be allowed after the block duration")
+	}
+
+	if err := rateLimiter.BlockKey(context.Background(), "ip:10.0.0.1", 3); err != nil {
+		t.Fatalf("Failed to block key: %v", err)
+	}
+	if allowed, _ := rateLimiter.AllowRequest(context.Background(), "10.0.0.1", false); allowed {
+		t.Fatalf("Request should be denied for an explicitly blocked key")
+	}
+}
@@ -0,0 +1,92 @@
+package limiter
+
+import (
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func quotaWindows(rules []domain.QuotaRule, now time.Time, location *time.Location) []domain.QuotaWindow {
+	local := now.In(location)
+	windows := make([]domain.QuotaWindow, 0, len(rules))
+	for _, rule := range rules {
+		var start, resetAt time.Time
+		switch rule.Period {
+		case domain.QuotaDaily:
+			start = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+			resetAt = start.AddDate(0, 0, 1)
+		case domain.QuotaMonthly:
+			start = time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, location)
+			resetAt = start.AddDate(0, 1, 0)
+		default:
+			continue
+		}
+		windows = append(windows, domain.QuotaWindow{
+			Period:  rule.Period,
+			Limit:   rule.Limit,
+			Start:   start,
+			ResetAt: resetAt,
+		})
+	}
+	return windows
+}
+
+func oversizedQuota(cost int64, windows []domain.QuotaWindow) (domain.QuotaResult, bool) {
+	result := domain.QuotaResult{Used: make([]int64, len(windows))}
+	oversized := false
+	for i, window := range windows {
+		if cost > window.Limit {
+			result.Used[i] = window.Limit
+			oversized = true
+		}
+	}
+	return result, oversized
+}
+
+func quotaUsage(windows []domain.QuotaWindow, used []int64) []domain.QuotaUsage {
+	usage := make([]domain.QuotaUsage, len(windows))
+	for i, window := range windows {
+		usage[i] = domain.QuotaUsage{
+			Period:    window.Period,
+			Limit:     window.Limit,
+			Used:      used[i],
+			Remaining: max(0, window.Limit-used[i]),
+			ResetAt:   window.ResetAt,
+		}
+	}
+	return usage
+}
+
+func quotaDecision(windows []domain.QuotaWindow, result domain.QuotaResult, now time.Time) domain.Decision {
+	decision := domain.Decision{Allowed: result.Allowed, Reason: domain.ReasonAllowed}
+	if len(windows) == 0 {
+		return decision
+	}
+
+	primary := -1
+	for i, window := range windows {
+		remaining := max(0, window.Limit-result.Used[i])
+		if result.Allowed {
+			if primary < 0 || remaining < max(0, windows[primary].Limit-result.Used[primary]) {
+				primary = i
+			}
+		} else if remaining == 0 && (primary < 0 || window.ResetAt.After(windows[primary].ResetAt)) {
+			primary = i
+		}
+	}
+	if primary < 0 {
+		primary = 0
+	}
+
+	window := windows[primary]
+	decision.Policy = domain.PolicyToken + "-" + window.Period
+	decision.Limit = window.Limit
+	decision.Remaining = max(0, window.Limit-result.Used[primary])
+	decision.Window = window.ResetAt.Sub(window.Start)
+	decision.ResetAt = window.ResetAt
+	if !result.Allowed {
+		decision.Reason = domain.ReasonQuotaExceeded
+		decision.RetryAfter = window.ResetAt.Sub(now)
+	}
+	return decision
+}
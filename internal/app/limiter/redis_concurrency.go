@@ -0,0 +1,73 @@
+package limiter
+
+import (
+	"context"
+
+	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"go.uber.org/zap"
+)
+
+type RedisConcurrencyLimiter struct {
+	store  domain.ConcurrencyStore
+	config domain.LimiterConfig
+}
+
+func NewRedisConcurrencyLimiter(store domain.ConcurrencyStore, config domain.LimiterConfig) *RedisConcurrencyLimiter {
+	return &RedisConcurrencyLimiter{
+		store:  store,
+		config: config,
+	}
+}
+
+func (r *RedisConcurrencyLimiter) Acquire(ctx context.Context, key string, isToken bool) (domain.Decision, domain.Lease, error) {
+	limit := int64(concurrencyLimitFor(r.config, isToken))
+	if limit <= 0 {
+		return domain.Decision{Allowed: true, Reason: domain.ReasonAllowed}, domain.Lease{}, nil
+	}
+
+	prefixedKey := prefixKey(key, isToken)
+	id := newLeaseID()
+	result, err := r.store.AcquireSlot(ctx, prefixedKey, id, limit, leaseFor(r.config).Microseconds())
+	if err != nil {
+		logger.Error("Store AcquireSlot failed", err, zap.String("prefixedKey", prefixedKey))
+		return domain.Decision{}, domain.Lease{}, err
+	}
+	logger.Debug("Store AcquireSlot result",
+		zap.String("prefixedKey", prefixedKey),
+		zap.Bool("acquired", result.Acquired),
+		zap.Int64("inFlight", result.InFlight),
+		zap.Int64("limit", limit),
+	)
+
+	if !result.Acquired {
+		return concurrencyDecision(result, limit, isToken), domain.Lease{}, nil
+	}
+	return concurrencyDecision(result, limit, isToken), domain.Lease{Key: prefixedKey, ID: id, Duration: leaseFor(r.config)}, nil
+}
+
+func (r *RedisConcurrencyLimiter) Renew(ctx context.Context, lease domain.Lease) error {
+	if lease.ID == "" {
+		return nil
+	}
+	renewed, err := r.store.RenewSlot(ctx, lease.Key, lease.ID, leaseFor(r.config).Microseconds())
+	if err != nil {
+		logger.Error("Store RenewSlot failed", err, zap.String("prefixedKey", lease.Key))
+		return err
+	}
+	if !renewed {
+		return ErrLeaseExpired
+	}
+	return nil
+}
+
+func (r *RedisConcurrencyLimiter) Release(ctx context.Context, lease domain.Lease) error {
+	if lease.ID == "" {
+		return nil
+	}
+	if err := r.store.ReleaseSlot(ctx, lease.Key, lease.ID); err != nil {
+		logger.Error("Store ReleaseSlot failed", err, zap.String("prefixedKey", lease.Key))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func TestRedisConcurrencyLimiter(t *testing.T) {
+	slots := map[string]bool{}
+	mockStore := &MockConcurrencyStore{
+		AcquireSlotFunc: func(ctx context.Context, key, id string, limit, lease int64) (domain.ConcurrencyResult, error) {
+			if key != "token:abc" || limit != 1 || lease != (10*time.Second).Microseconds() {
+				t.Fatalf("Unexpected call: %s %d %d", key, limit, lease)
+			}
+			if int64(len(slots)) >= limit {
+				return domain.ConcurrencyResult{InFlight: int64(len(slots))}, nil
+			}
+			slots[id] = true
+			return domain.ConcurrencyResult{Acquired: true, InFlight: int64(len(slots))}, nil
+		},
+		ReleaseSlotFunc: func(ctx context.Context, key, id string) error {
+			if !slots[id] {
+				return errors.New("unknown lease")
+			}
+			delete(slots, id)
+			return nil
+		},
+	}
+
+	concurrencyLimiter := NewRedisConcurrencyLimiter(mockStore, domain.LimiterConfig{
+		TokenMaxConcurrent: 1,
+		LeaseDuration:      10 * time.Second,
+	})
+
+	decision, lease, err := concurrencyLimiter.Acquire(context.Background(), "abc", true)
+	if err != nil || !decision.Allowed || lease.Key != "token:abc" || decision.Policy != "token-concurrency" {
+		t.Fatalf("Expected the slot to be acquired, got %+v %+v (%v)", decision, lease, err)
+	}
+	if decision, _, _ := concurrencyLimiter.Acquire(context.Background(), "abc", true); decision.Allowed || decision.RetryAfter <= 0 {
+		t.Fatalf("Expected the second in-flight request to be denied, got %+v", decision)
+	}
+	if err := concurrencyLimiter.Release(context.Background(), lease); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decision, _, _ := concurrencyLimiter.Acquire(context.Background(), "abc", true); !decision.Allowed {
+		t.Fatalf("Expected the released slot to be reused, got %+v", decision)
+	}
+	if err := concurrencyLimiter.Release(context.Background(), domain.Lease{}); err != nil {
+		t.Fatalf("Releasing an empty lease should be a no-op, got %v", err)
+	}
+}
+
+func TestRedisConcurrencyLimiter_Renew(t *testing.T) {
+	mockStore := &MockConcurrencyStore{
+		AcquireSlotFunc: func(ctx context.Context, key, id string, limit, lease int64) (domain.ConcurrencyResult, error) {
+			return domain.ConcurrencyResult{Acquired: true, InFlight: 1}, nil
+		},
+		RenewSlotFunc: func(ctx context.Context, key, id string, lease int64) (bool, error) {
+			if key != "ip:192.168.1.1" || lease != (10*time.Second).Microseconds() {
+				t.Fatalf("Unexpected call: %s %d", key, lease)
+			}
+			return id == "live", nil
+		},
+	}
+	concurrencyLimiter := NewRedisConcurrencyLimiter(mockStore, domain.LimiterConfig{
+		MaxConcurrent: 1,
+		LeaseDuration: 10 * time.Second,
+	})
+
+	_, lease, _ := concurrencyLimiter.Acquire(context.Background(), "192.168.1.1", false)
+	if lease.Duration != 10*time.Second {
+		t.Fatalf("Expected the lease to carry its duration, got %+v", lease)
+	}
+	if err := concurrencyLimiter.Renew(context.Background(), domain.Lease{Key: lease.Key, ID: "live"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := concurrencyLimiter.Renew(context.Background(), lease); !errors.Is(err, ErrLeaseExpired) {
+		t.Fatalf("Expected ErrLeaseExpired for a lease Redis no longer holds, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package limiter
+
+import (
+	"context"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"go.uber.org/zap"
+)
+
+type RedisGCRALimiter struct {
+	storeBlockManager
+	store  domain.GCRAStore
+	config domain.LimiterConfig
+}
+
+func NewRedisGCRALimiter(store domain.GCRAStore, config domain.LimiterConfig) *RedisGCRALimiter {
+	return &RedisGCRALimiter{
+		storeBlockManager: storeBlockManager{blocks: store},
+		store:             store,
+		config:            config,
+	}
+}
+
+func (r *RedisGCRALimiter) AllowRequest(ctx context.Context, key string, isToken bool) (bool, error) {
+	decision, err := r.Evaluate(ctx, key, isToken)
+	return decision.Allowed, err
+}
+
+func (r *RedisGCRALimiter) Evaluate(ctx context.Context, key string, isToken bool) (domain.Decision, error) {
+	return r.EvaluateCost(ctx, key, isToken, 1)
+}
+
+func (r *RedisGCRALimiter) EvaluateCost(ctx context.Context, key string, isToken bool, cost int64) (domain.Decision, error) {
+	prefixedKey := prefixKey(key, isToken)
+	burst := int64(burstFor(r.config, isToken))
+	interval := emissionIntervalFor(r.config, isToken)
+	if interval == 0 {
+		return decisionFromResult(domain.StoreResult{}, 0, 0, isToken, time.Now()), nil
+	}
+	if decision, oversized := oversizedDecision(cost, burst, burstWindowFor(r.config, isToken), isToken, time.Now()); oversized {
+		return decision, nil
+	}
+
+	result, err := r.store.UpdateTAT(ctx, prefixedKey, interval.Microseconds(), burst, r.config.BlockDuration, cost)
+	if err != nil {
+		logger.Error("Store UpdateTAT failed", err, zap.String("prefixedKey", prefixedKey))
+		return domain.Decision{}, err
+	}
+	logger.Debug("Store UpdateTAT result",
+		zap.String("prefixedKey", prefixedKey),
+		zap.Bool("allowed", result.Allowed),
+		zap.Duration("retryAfter", result.RetryAfter),
+		zap.Int64("remaining", result.Remaining),
+	)
+
+	return decisionFromResult(result, burst, burstWindowFor(r.config, isToken), isToken, time.Now()), nil
+}
+
+func (r *RedisGCRALimiter) BlockKey(ctx context.Context, key string, duration int64) error {
+	return r.setBlock(ctx, key, domain.BlockReasonManual, duration)
+}
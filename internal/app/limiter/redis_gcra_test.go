@@ -0,0 +1,49 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func TestRedisGCRALimiter_Evaluate(t *testing.T) {
+	mockStore := &MockGCRAStore{
+		UpdateTATFunc: func(ctx context.Context, key string, emissionInterval, burst, blockDuration, cost int64) (domain.StoreResult, error) {
+			if key != "token:abc" {
+				t.Fatalf("Unexpected key %s", key)
+			}
+			if emissionInterval != 200000 || burst != 5 || blockDuration != 2 {
+				t.Fatalf("Unexpected GCRA parameters: %d/%d/%d", emissionInterval, burst, blockDuration)
+			}
+			return domain.StoreResult{RetryAfter: 150 * time.Millisecond, ResetAfter: time.Second}, nil
+		},
+		MockBlockStore: MockBlockStore{
+			SetBlockFunc: func(ctx context.Context, key, reason string, duration int64) error {
+				if key != "token:abc" || reason != domain.BlockReasonManual || duration != 3 {
+					t.Fatalf("Unexpected block for %s: %s/%d", key, reason, duration)
+				}
+				return nil
+			},
+		},
+	}
+
+	redisLimiter := NewRedisGCRALimiter(mockStore, domain.LimiterConfig{
+		MaxRequests:      1,
+		TokenMaxRequests: 5,
+		BlockDuration:    2,
+	})
+
+	decision, err := redisLimiter.Evaluate(context.Background(), "abc", true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decision.Allowed || decision.RetryAfter != 150*time.Millisecond || decision.Limit != 5 {
+		t.Fatalf("Expected denial with 150ms retry-after, got %+v", decision)
+	}
+
+	if err := redisLimiter.BlockKey(context.Background(), "token:abc", 3); err != nil {
+		t.Fatalf("Failed to block key: %v", err)
+	}
+}
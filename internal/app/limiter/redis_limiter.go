@@ -2,6 +2,8 @@ package limiter
 
 import (
 	"context"
+	"math"
+	"time"
 
 	"github.com/ankardo/Rate-Limiter/config/logger"
 	"github.com/ankardo/Rate-Limiter/internal/domain"
@@ -9,47 +11,74 @@ import (
 )
 
 type RedisRateLimiter struct {
+	storeBlockManager
 	store  domain.RateLimiterStore
 	config domain.LimiterConfig
-	ctx    context.Context
 }
 
 func NewRedisRateLimiter(store domain.RateLimiterStore, config domain.LimiterConfig) *RedisRateLimiter {
+	blocks, _ := store.(domain.BlockStore)
 	return &RedisRateLimiter{
-		store:  store,
-		config: config,
-		ctx:    context.Background(),
+		storeBlockManager: storeBlockManager{blocks: blocks},
+		store:             store,
+		config:            config,
 	}
 }
 
-func (r *RedisRateLimiter) AllowRequest(key string, isToken bool) (bool, error) {
-	prefixedKey := key
-	if isToken {
-		prefixedKey = "token:" + key
-	} else {
-		prefixedKey = "ip:" + key
-	}
+func (r *RedisRateLimiter) AllowRequest(ctx context.Context, key string, isToken bool) (bool, error) {
+	decision, err := r.Evaluate(ctx, key, isToken)
+	return decision.Allowed, err
+}
+
+func (r *RedisRateLimiter) Evaluate(ctx context.Context, key string, isToken bool) (domain.Decision, error) {
+	return r.EvaluateCost(ctx, key, isToken, 1)
+}
+
+func (r *RedisRateLimiter) EvaluateCost(ctx context.Context, key string, isToken bool, cost int64) (domain.Decision, error) {
+	prefixedKey := prefixKey(key, isToken)
 
-	logger.Debug("AllowRequest called",
+	logger.Debug("Evaluate called",
 		zap.String("key", key),
 		zap.Bool("isToken", isToken),
 		zap.String("expectedPrefix", prefixedKey[:3]),
 	)
 
-	count, err := r.store.Increment(prefixedKey)
+	limit := int64(limitFor(r.config, isToken))
+	window := windowFor(r.config, isToken)
+	if decision, oversized := oversizedDecision(cost, limit, window, isToken, time.Now()); oversized {
+		return decision, nil
+	}
+
+	if atomicStore, ok := r.store.(domain.AtomicRateLimiterStore); ok {
+		return r.evaluateAtomic(ctx, atomicStore, prefixedKey, limit, window, isToken, cost)
+	}
+
+	if r.blocks != nil {
+		blockedFor, err := r.blocks.BlockedFor(ctx, prefixedKey)
+		if err != nil {
+			logger.Error("Store BlockedFor failed", err, zap.String("prefixedKey", prefixedKey))
+			return domain.Decision{}, err
+		}
+		if blockedFor > 0 {
+			result := domain.StoreResult{Blocked: true, RetryAfter: blockedFor, ResetAfter: blockedFor}
+			return decisionFromResult(result, limit, window, isToken, time.Now()), nil
+		}
+	}
+
+	count, err := r.store.Increment(ctx, prefixedKey, cost)
 	if err != nil {
 		logger.Error("Store Increment failed", err, zap.String("prefixedKey", prefixedKey))
-		return false, err
+		return domain.Decision{}, err
 	}
 	logger.Debug("Store Increment result",
 		zap.String("prefixedKey", prefixedKey),
 		zap.Int64("count", count),
 	)
 
-	ttl, err := r.store.GetTTL(prefixedKey)
+	ttl, err := r.store.GetTTL(ctx, prefixedKey)
 	if err != nil {
 		logger.Error("Store GetTTL failed", err, zap.String("prefixedKey", prefixedKey))
-		return false, err
+		return domain.Decision{}, err
 	}
 	logger.Debug("Store TTL result",
 		zap.String("prefixedKey", prefixedKey),
@@ -57,20 +86,20 @@ func (r *RedisRateLimiter) AllowRequest(key string, isToken bool) (bool, error)
 	)
 
 	if ttl < 0 {
-		err := r.store.SetExpiration(prefixedKey, r.config.TTLExpiration)
+		expiration := int64(math.Ceil(window.Seconds()))
+		err := r.store.SetExpiration(ctx, prefixedKey, expiration)
 		if err != nil {
 			logger.Error("Store SetExpiration failed", err, zap.String("prefixedKey", prefixedKey))
-			return false, err
+			return domain.Decision{}, err
 		}
+		ttl = expiration
 		logger.Debug("Store Expiration set",
 			zap.String("prefixedKey", prefixedKey),
-			zap.Int64("expiry", r.config.TTLExpiration),
+			zap.Int64("expiry", expiration),
 		)
 	}
 
-	limit := int64(r.config.MaxRequests)
 	if isToken {
-		limit = int64(r.config.TokenMaxRequests)
 		logger.Debug("Token limit check",
 			zap.String("prefixedKey", prefixedKey),
 			zap.Int64("TokenMaxRequests", limit),
@@ -83,16 +112,57 @@ func (r *RedisRateLimiter) AllowRequest(key string, isToken bool) (bool, error)
 			zap.Int64("count", count),
 			zap.Int64("limit", limit),
 		)
-		_ = r.BlockKey(prefixedKey, r.config.BlockDuration)
-		return false, nil
+		if r.blocks != nil {
+			if r.config.BlockDuration > 0 {
+				_ = r.setBlock(ctx, prefixedKey, domain.ReasonLimitExceeded, r.config.BlockDuration)
+			}
+		} else {
+			_ = r.BlockKey(ctx, prefixedKey, r.config.BlockDuration)
+		}
+		if r.config.BlockDuration > 0 {
+			ttl = r.config.BlockDuration
+		}
+	}
+
+	return r.windowDecision(count, ttl, limit, window, isToken), nil
+}
+
+func (r *RedisRateLimiter) evaluateAtomic(ctx context.Context, store domain.AtomicRateLimiterStore, prefixedKey string, limit int64, window time.Duration, isToken bool, cost int64) (domain.Decision, error) {
+	result, err := store.IncrementAndCheck(ctx, prefixedKey, limit, window.Microseconds(), r.config.BlockDuration, cost)
+	if err != nil {
+		logger.Error("Store IncrementAndCheck failed", err, zap.String("prefixedKey", prefixedKey))
+		return domain.Decision{}, err
 	}
+	logger.Debug("Store IncrementAndCheck result",
+		zap.String("prefixedKey", prefixedKey),
+		zap.Bool("allowed", result.Allowed),
+		zap.Int64("remaining", result.Remaining),
+		zap.Int64("limit", limit),
+	)
 
-	return true, nil
+	return decisionFromResult(result, limit, window, isToken, time.Now()), nil
 }
 
-func (r *RedisRateLimiter) BlockKey(key string, duration int64) error {
+func (r *RedisRateLimiter) windowDecision(count, ttl, limit int64, window time.Duration, isToken bool) domain.Decision {
+	resetAfter := time.Duration(ttl) * time.Second
+	result := domain.StoreResult{
+		Allowed:    count <= limit,
+		Blocked:    count > limit+1 && r.config.BlockDuration > 0,
+		Remaining:  max(0, limit-count),
+		ResetAfter: resetAfter,
+	}
+	if !result.Allowed {
+		result.RetryAfter = resetAfter
+	}
+	return decisionFromResult(result, limit, window, isToken, time.Now())
+}
+
+func (r *RedisRateLimiter) BlockKey(ctx context.Context, key string, duration int64) error {
+	if r.blocks != nil {
+		return r.setBlock(ctx, key, domain.BlockReasonManual, duration)
+	}
 	logger.Debug("Blocking key", zap.String("key", key), zap.Int64("duration", duration))
-	err := r.store.SetExpiration(key, duration)
+	err := r.store.SetExpiration(ctx, key, duration)
 	if err != nil {
 		logger.Error("Store BlockKey failed", err, zap.String("key", key))
 		return err
@@ -1,30 +1,186 @@
 package limiter
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
 
 type MockRedisStore struct {
-	SetExpirationFunc func(key string, duration int64) error
-	GetTTLFunc        func(key string) (int64, error)
-	IncrementFunc     func(key string) (int64, error)
+	SetExpirationFunc func(ctx context.Context, key string, duration int64) error
+	GetTTLFunc        func(ctx context.Context, key string) (int64, error)
+	IncrementFunc     func(ctx context.Context, key string, cost int64) (int64, error)
 }
 
-func (m *MockRedisStore) SetExpiration(key string, duration int64) error {
+func (m *MockRedisStore) SetExpiration(ctx context.Context, key string, duration int64) error {
 	if m.SetExpirationFunc != nil {
-		return m.SetExpirationFunc(key, duration)
+		return m.SetExpirationFunc(ctx, key, duration)
 	}
 	return errors.New("SetExpirationFunc not implemented")
 }
 
-func (m *MockRedisStore) GetTTL(key string) (int64, error) {
+func (m *MockRedisStore) GetTTL(ctx context.Context, key string) (int64, error) {
 	if m.GetTTLFunc != nil {
-		return m.GetTTLFunc(key)
+		return m.GetTTLFunc(ctx, key)
 	}
 	return 0, errors.New("GetTTLFunc not implemented")
 }
 
-func (m *MockRedisStore) Increment(key string) (int64, error) {
+func (m *MockRedisStore) Increment(ctx context.Context, key string, cost int64) (int64, error) {
 	if m.IncrementFunc != nil {
-		return m.IncrementFunc(key)
+		return m.IncrementFunc(ctx, key, cost)
 	}
 	return 0, errors.New("IncrementFunc not implemented")
 }
+
+type MockAtomicRedisStore struct {
+	MockRedisStore
+	MockBlockStore
+	IncrementAndCheckFunc func(ctx context.Context, key string, limit, window, blockDuration, cost int64) (domain.StoreResult, error)
+}
+
+func (m *MockAtomicRedisStore) IncrementAndCheck(ctx context.Context, key string, limit, window, blockDuration, cost int64) (domain.StoreResult, error) {
+	if m.IncrementAndCheckFunc != nil {
+		return m.IncrementAndCheckFunc(ctx, key, limit, window, blockDuration, cost)
+	}
+	return domain.StoreResult{}, errors.New("IncrementAndCheckFunc not implemented")
+}
+
+type MockBlockStore struct {
+	SetBlockFunc    func(ctx context.Context, key, reason string, duration int64) error
+	BlockedForFunc  func(ctx context.Context, key string) (time.Duration, error)
+	ListBlocksFunc  func(ctx context.Context) ([]domain.Block, error)
+	DeleteBlockFunc func(ctx context.Context, key string) error
+}
+
+func (m *MockBlockStore) SetBlock(ctx context.Context, key, reason string, duration int64) error {
+	if m.SetBlockFunc != nil {
+		return m.SetBlockFunc(ctx, key, reason, duration)
+	}
+	return errors.New("SetBlockFunc not implemented")
+}
+
+func (m *MockBlockStore) BlockedFor(ctx context.Context, key string) (time.Duration, error) {
+	if m.BlockedForFunc != nil {
+		return m.BlockedForFunc(ctx, key)
+	}
+	return 0, errors.New("BlockedForFunc not implemented")
+}
+
+func (m *MockBlockStore) ListBlocks(ctx context.Context) ([]domain.Block, error) {
+	if m.ListBlocksFunc != nil {
+		return m.ListBlocksFunc(ctx)
+	}
+	return nil, errors.New("ListBlocksFunc not implemented")
+}
+
+func (m *MockBlockStore) DeleteBlock(ctx context.Context, key string) error {
+	if m.DeleteBlockFunc != nil {
+		return m.DeleteBlockFunc(ctx, key)
+	}
+	return errors.New("DeleteBlockFunc not implemented")
+}
+
+type MockTokenBucketStore struct {
+	MockBlockStore
+	TakeTokenFunc func(ctx context.Context, key string, capacity int64, refillPerSecond float64, blockDuration, cost int64) (domain.StoreResult, error)
+}
+
+func (m *MockTokenBucketStore) TakeToken(ctx context.Context, key string, capacity int64, refillPerSecond float64, blockDuration, cost int64) (domain.StoreResult, error) {
+	if m.TakeTokenFunc != nil {
+		return m.TakeTokenFunc(ctx, key, capacity, refillPerSecond, blockDuration, cost)
+	}
+	return domain.StoreResult{}, errors.New("TakeTokenFunc not implemented")
+}
+
+type MockGCRAStore struct {
+	MockBlockStore
+	UpdateTATFunc func(ctx context.Context, key string, emissionInterval, burst, blockDuration, cost int64) (domain.StoreResult, error)
+}
+
+func (m *MockGCRAStore) UpdateTAT(ctx context.Context, key string, emissionInterval, burst, blockDuration, cost int64) (domain.StoreResult, error) {
+	if m.UpdateTATFunc != nil {
+		return m.UpdateTATFunc(ctx, key, emissionInterval, burst, blockDuration, cost)
+	}
+	return domain.StoreResult{}, errors.New("UpdateTATFunc not implemented")
+}
+
+type MockSlidingWindowStore struct {
+	MockBlockStore
+	AddToLogFunc               func(ctx context.Context, key, member string, limit, window, blockDuration, cost int64) (domain.StoreResult, error)
+	IncrementWindowCounterFunc func(ctx context.Context, key string, limit, window, blockDuration, cost int64) (domain.StoreResult, error)
+}
+
+func (m *MockSlidingWindowStore) AddToLog(ctx context.Context, key, member string, limit, window, blockDuration, cost int64) (domain.StoreResult, error) {
+	if m.AddToLogFunc != nil {
+		return m.AddToLogFunc(ctx, key, member, limit, window, blockDuration, cost)
+	}
+	return domain.StoreResult{}, errors.New("AddToLogFunc not implemented")
+}
+
+func (m *MockSlidingWindowStore) IncrementWindowCounter(ctx context.Context, key string, limit, window, blockDuration, cost int64) (domain.StoreResult, error) {
+	if m.IncrementWindowCounterFunc != nil {
+		return m.IncrementWindowCounterFunc(ctx, key, limit, window, blockDuration, cost)
+	}
+	return domain.StoreResult{}, errors.New("IncrementWindowCounterFunc not implemented")
+}
+
+type MockStackedLimitStore struct {
+	MockBlockStore
+	IncrementLimitsFunc func(ctx context.Context, key string, rules []domain.LimitRule, blockDuration, cost int64) (domain.StackedResult, error)
+}
+
+func (m *MockStackedLimitStore) IncrementLimits(ctx context.Context, key string, rules []domain.LimitRule, blockDuration, cost int64) (domain.StackedResult, error) {
+	if m.IncrementLimitsFunc != nil {
+		return m.IncrementLimitsFunc(ctx, key, rules, blockDuration, cost)
+	}
+	return domain.StackedResult{}, errors.New("IncrementLimitsFunc not implemented")
+}
+
+type MockQuotaStore struct {
+	ConsumeQuotaFunc func(ctx context.Context, key string, windows []domain.QuotaWindow, cost int64) (domain.QuotaResult, error)
+	QuotaUsedFunc    func(ctx context.Context, key string, windows []domain.QuotaWindow) ([]int64, error)
+}
+
+func (m *MockQuotaStore) ConsumeQuota(ctx context.Context, key string, windows []domain.QuotaWindow, cost int64) (domain.QuotaResult, error) {
+	if m.ConsumeQuotaFunc != nil {
+		return m.ConsumeQuotaFunc(ctx, key, windows, cost)
+	}
+	return domain.QuotaResult{}, errors.New("ConsumeQuotaFunc not implemented")
+}
+
+func (m *MockQuotaStore) QuotaUsed(ctx context.Context, key string, windows []domain.QuotaWindow) ([]int64, error) {
+	if m.QuotaUsedFunc != nil {
+		return m.QuotaUsedFunc(ctx, key, windows)
+	}
+	return nil, errors.New("QuotaUsedFunc not implemented")
+}
+
+type MockConcurrencyStore struct {
+	AcquireSlotFunc func(ctx context.Context, key, id string, limit, lease int64) (domain.ConcurrencyResult, error)
+	RenewSlotFunc   func(ctx context.Context, key, id string, lease int64) (bool, error)
+	ReleaseSlotFunc func(ctx context.Context, key, id string) error
+}
+
+func (m *MockConcurrencyStore) AcquireSlot(ctx context.Context, key, id string, limit, lease int64) (domain.ConcurrencyResult, error) {
+	if m.AcquireSlotFunc != nil {
+		return m.AcquireSlotFunc(ctx, key, id, limit, lease)
+	}
+	return domain.ConcurrencyResult{}, errors.New("AcquireSlotFunc not implemented")
+}
+
+func (m *MockConcurrencyStore) RenewSlot(ctx context.Context, key, id string, lease int64) (bool, error) {
+	if m.RenewSlotFunc != nil {
+		return m.RenewSlotFunc(ctx, key, id, lease)
+	}
+	return false, errors.New("RenewSlotFunc not implemented")
+}
+
+func (m *MockConcurrencyStore) ReleaseSlot(ctx context.Context, key, id string) error {
+	if m.ReleaseSlotFunc != nil {
+		return m.ReleaseSlotFunc(ctx, key, id)
+	}
+	return errors.New("ReleaseSlotFunc not implemented")
+}
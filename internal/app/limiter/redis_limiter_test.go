@@ -1,6 +1,7 @@
 package limiter
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -10,13 +11,13 @@ import (
 
 func TestRedisRateLimiter_BlockKey(t *testing.T) {
 	mockStore := &MockRedisStore{
-		SetExpirationFunc: func(key string, duration int64) error {
+		SetExpirationFunc: func(ctx context.Context, key string, duration int64) error {
 			if key == "test-block-key" {
 				return nil
 			}
 			return errors.New("failed to set expiration")
 		},
-		GetTTLFunc: func(key string) (int64, error) {
+		GetTTLFunc: func(ctx context.Context, key string) (int64, error) {
 			if key == "test-block-key" {
 				return 5, nil
 			}
@@ -32,12 +33,12 @@ func TestRedisRateLimiter_BlockKey(t *testing.T) {
 
 	key := "test-block-key"
 
-	err := redisLimiter.BlockKey(key, 5)
+	err := redisLimiter.BlockKey(context.Background(), key, 5)
 	if err != nil {
 		t.Fatalf("Failed to block key: %v", err)
 	}
 
-	ttl, err := mockStore.GetTTL(key)
+	ttl, err := mockStore.GetTTL(context.Background(), key)
 	if err != nil {
 		t.Fatalf("Failed to get key TTL: %v", err)
 	}
@@ -46,14 +47,204 @@ func TestRedisRateLimiter_BlockKey(t *testing.T) {
 		t.Fatalf("Expected TTL > 0 for key %s, got %d", key, ttl)
 	}
 
-	mockStore.GetTTLFunc = func(key string) (int64, error) {
+	mockStore.GetTTLFunc = func(ctx context.Context, key string) (int64, error) {
 		return 0, errors.New("key expired")
 	}
 
 	time.Sleep(6 * time.Second)
 
-	ttl, err = mockStore.GetTTL(key)
+	ttl, err = mockStore.GetTTL(context.Background(), key)
 	if err == nil {
 		t.Fatalf("Key %s should be expired, but still has TTL: %d", key, ttl)
 	}
 }
+
+func TestRedisRateLimiter_AllowRequestAtomic(t *testing.T) {
+	counts := map[string]int64{}
+	mockStore := &MockAtomicRedisStore{
+		MockRedisStore: MockRedisStore{
+			IncrementFunc: func(ctx context.Context, key string, cost int64) (int64, error) {
+				t.Fatalf("Increment should not be called when the store supports IncrementAndCheck")
+				return 0, nil
+			},
+		},
+		IncrementAndCheckFunc: func(ctx context.Context, key string, limit, window, blockDuration, cost int64) (domain.StoreResult, error) {
+			if window != time.Second.Microseconds() || blockDuration != 5 {
+				t.Fatalf("Unexpected window/blockDuration: %d/%d", window, blockDuration)
+			}
+			counts[key]++
+			return domain.StoreResult{
+				Allowed:    counts[key] <= limit,
+				Remaining:  max(0, limit-counts[key]),
+				ResetAfter: time.Duration(window) * time.Microsecond,
+			}, nil
+		},
+	}
+
+	redisLimiter := NewRedisRateLimiter(mockStore, domain.LimiterConfig{
+		MaxRequests:      2,
+		TokenMaxRequests: 3,
+		BlockDuration:    5,
+	})
+
+	tests := []struct {
+		name         string
+		key          string
+		isToken      bool
+		expectStatus []bool
+	}{
+		{name: "Limit by IP", key: "10.0.0.1", isToken: false, expectStatus: []bool{true, true, false}},
+		{name: "Limit by Token", key: "abc", isToken: true, expectStatus: []bool{true, true, true, false}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i, expected := range test.expectStatus {
+				allowed, err := redisLimiter.AllowRequest(context.Background(), test.key, test.isToken)
+				if err != nil {
+					t.Fatalf("Request %d: unexpected error: %v", i+1, err)
+				}
+				if allowed != expected {
+					t.Fatalf("Request %d: expected %v, got %v", i+1, expected, allowed)
+				}
+			}
+		})
+	}
+
+	if counts["ip:10.0.0.1"] != 3 || counts["token:abc"] != 4 {
+		t.Fatalf("Unexpected prefixed key counts: %v", counts)
+	}
+
+	decision, err := redisLimiter.Evaluate(context.Background(), "10.0.0.2", false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !decision.Allowed || decision.Limit != 2 || decision.Remaining != 1 || decision.Policy != domain.PolicyIP {
+		t.Fatalf("Unexpected decision: %+v", decision)
+	}
+}
+
+func TestRedisRateLimiter_Blocks(t *testing.T) {
+	blocks := map[string]string{}
+	mockStore := &MockAtomicRedisStore{
+		MockBlockStore: MockBlockStore{
+			SetBlockFunc: func(ctx context.Context, key, reason string, duration int64) error {
+				blocks[key] = reason
+				return nil
+			},
+			ListBlocksFunc: func(ctx context.Context) ([]domain.Block, error) {
+				var list []domain.Block
+				for key, reason := range blocks {
+					list = append(list, domain.Block{Key: key, Reason: reason})
+				}
+				return list, nil
+			},
+			DeleteBlockFunc: func(ctx context.Context, key string) error {
+				delete(blocks, key)
+				return nil
+			},
+		},
+	}
+
+	redisLimiter := NewRedisRateLimiter(mockStore, domain.LimiterConfig{MaxRequests: 1})
+
+	if err := redisLimiter.BlockKey(context.Background(), "ip:10.0.0.1", 5); err != nil {
+		t.Fatalf("Failed to block key: %v", err)
+	}
+	list, err := redisLimiter.ListBlocks(context.Background())
+	if err != nil || len(list) != 1 || list[0].Key != "ip:10.0.0.1" || list[0].Reason != domain.BlockReasonManual {
+		t.Fatalf("Unexpected blocks: %+v (%v)", list, err)
+	}
+	if err := redisLimiter.LiftBlock(context.Background(), "ip:10.0.0.1"); err != nil {
+		t.Fatalf("Failed to lift block: %v", err)
+	}
+	if len(blocks) != 0 {
+		t.Fatalf("Expected block to be lifted, got %v", blocks)
+	}
+
+	legacyLimiter := NewRedisRateLimiter(&MockRedisStore{}, domain.LimiterConfig{MaxRequests: 1})
+	if _, err := legacyLimiter.ListBlocks(context.Background()); !errors.Is(err, ErrBlocksUnsupported) {
+		t.Fatalf("Expected ErrBlocksUnsupported, got %v", err)
+	}
+}
+
+type blockingRedisStore struct {
+	MockRedisStore
+	MockBlockStore
+}
+
+func TestRedisRateLimiter_NonAtomicBlocks(t *testing.T) {
+	counts := map[string]int64{}
+	blocks := map[string]string{}
+	mockStore := &blockingRedisStore{
+		MockRedisStore: MockRedisStore{
+			IncrementFunc: func(ctx context.Context, key string, cost int64) (int64, error) {
+				counts[key] += cost
+				return counts[key], nil
+			},
+			GetTTLFunc: func(ctx context.Context, key string) (int64, error) {
+				return 1, nil
+			},
+		},
+		MockBlockStore: MockBlockStore{
+			SetBlockFunc: func(ctx context.Context, key, reason string, duration int64) error {
+				if duration != 10 {
+					t.Fatalf("Expected the configured block duration, got %d", duration)
+				}
+				blocks[key] = reason
+				return nil
+			},
+			BlockedForFunc: func(ctx context.Context, key string) (time.Duration, error) {
+				if _, blocked := blocks[key]; blocked {
+					return 10 * time.Second, nil
+				}
+				return 0, nil
+			},
+		},
+	}
+	redisLimiter := NewRedisRateLimiter(mockStore, domain.LimiterConfig{MaxRequests: 2, Window: time.Second, BlockDuration: 10})
+
+	reasons := []string{domain.ReasonAllowed, domain.ReasonAllowed, domain.ReasonLimitExceeded, domain.ReasonBlocked}
+	for i, reason := range reasons {
+		decision, err := redisLimiter.Evaluate(context.Background(), "10.0.0.1", false)
+		if err != nil {
+			t.Fatalf("Request %d: unexpected error: %v", i+1, err)
+		}
+		if decision.Reason != reason {
+			t.Fatalf("Request %d: expected %s, got %+v", i+1, reason, decision)
+		}
+	}
+	if blocks["ip:10.0.0.1"] != domain.ReasonLimitExceeded {
+		t.Fatalf("Expected the block to record the limit reason, got %v", blocks)
+	}
+	if counts["ip:10.0.0.1"] != 3 {
+		t.Fatalf("Blocked requests should not be counted, got %v", counts)
+	}
+}
+
+type traceKey struct{}
+
+func TestRedisRateLimiter_PropagatesContext(t *testing.T) {
+	mockStore := &MockAtomicRedisStore{
+		IncrementAndCheckFunc: func(ctx context.Context, key string, limit, window, blockDuration, cost int64) (domain.StoreResult, error) {
+			if ctx.Value(traceKey{}) != "span-1" {
+				t.Fatalf("Expected the caller's context to reach the store")
+			}
+			if err := ctx.Err(); err != nil {
+				return domain.StoreResult{}, err
+			}
+			return domain.StoreResult{Allowed: true, Remaining: limit - 1}, nil
+		},
+	}
+	redisLimiter := NewRedisRateLimiter(mockStore, domain.LimiterConfig{MaxRequests: 5})
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), traceKey{}, "span-1"))
+	if allowed, err := redisLimiter.AllowRequest(ctx, "10.0.0.1", false); err != nil || !allowed {
+		t.Fatalf("Expected the request to be allowed, got %v/%v", allowed, err)
+	}
+
+	cancel()
+	if _, err := redisLimiter.Evaluate(ctx, "10.0.0.1", false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected a canceled context to abort the store call, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package limiter
+
+import (
+	"context"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"go.uber.org/zap"
+)
+
+type RedisQuotaLimiter struct {
+	store    domain.QuotaStore
+	rules    []domain.QuotaRule
+	location *time.Location
+	now      func() time.Time
+}
+
+func NewRedisQuotaLimiter(store domain.QuotaStore, rules []domain.QuotaRule, location *time.Location) *RedisQuotaLimiter {
+	if location == nil {
+		location = time.UTC
+	}
+	return &RedisQuotaLimiter{
+		store:    store,
+		rules:    rules,
+		location: location,
+		now:      time.Now,
+	}
+}
+
+func (r *RedisQuotaLimiter) EvaluateQuota(ctx context.Context, token string, cost int64) (domain.Decision, error) {
+	prefixedKey := prefixKey(token, true)
+	now := r.now()
+	windows := quotaWindows(r.rules, now, r.location)
+	if len(windows) == 0 {
+		return domain.Decision{Allowed: true, Reason: domain.ReasonAllowed}, nil
+	}
+
+	if result, oversized := oversizedQuota(cost, windows); oversized {
+		return quotaDecision(windows, result, now), nil
+	}
+
+	result, err := r.store.ConsumeQuota(ctx, prefixedKey, windows, cost)
+	if err != nil {
+		logger.Error("Store ConsumeQuota failed", err, zap.String("prefixedKey", prefixedKey))
+		return domain.Decision{}, err
+	}
+	logger.Debug("Store ConsumeQuota result",
+		zap.String("prefixedKey", prefixedKey),
+		zap.Bool("allowed", result.Allowed),
+		zap.Int64s("used", result.Used),
+	)
+
+	return quotaDecision(windows, result, now), nil
+}
+
+func (r *RedisQuotaLimiter) Usage(ctx context.Context, token string) ([]domain.QuotaUsage, error) {
+	prefixedKey := prefixKey(token, true)
+	windows := quotaWindows(r.rules, r.now(), r.location)
+
+	used, err := r.store.QuotaUsed(ctx, prefixedKey, windows)
+	if err != nil {
+		logger.Error("Store QuotaUsed failed", err, zap.String("prefixedKey", prefixedKey))
+		return nil, err
+	}
+	return quotaUsage(windows, used), nil
+}
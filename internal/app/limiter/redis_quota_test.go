@@ -0,0 +1,116 @@
+package limiter
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func TestQuotaWindows_CalendarAligned(t *testing.T) {
+	location, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Skipf("Timezone data unavailable: %v", err)
+	}
+	rules := []domain.QuotaRule{
+		{Period: domain.QuotaDaily, Limit: 100},
+		{Period: domain.QuotaMonthly, Limit: 1000},
+	}
+
+	now := time.Date(2024, time.February, 29, 2, 30, 0, 0, time.UTC)
+	windows := quotaWindows(rules, now, time.UTC)
+	if !windows[0].Start.Equal(time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)) || !windows[0].ResetAt.Equal(time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected UTC daily window: %+v", windows[0])
+	}
+	if !windows[1].Start.Equal(time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)) || !windows[1].ResetAt.Equal(time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected UTC monthly window: %+v", windows[1])
+	}
+
+	windows = quotaWindows(rules, now, location)
+	if !windows[0].Start.Equal(time.Date(2024, time.February, 28, 0, 0, 0, 0, location)) {
+		t.Fatalf("Expected the daily window to follow the configured timezone, got %+v", windows[0])
+	}
+	if !windows[0].ResetAt.Equal(time.Date(2024, time.February, 29, 3, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected the daily reset at local midnight, got %v", windows[0].ResetAt)
+	}
+}
+
+func TestRedisQuotaLimiter_EvaluateQuota(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC)
+	used := map[string]int64{}
+	mockStore := &MockQuotaStore{
+		ConsumeQuotaFunc: func(ctx context.Context, key string, windows []domain.QuotaWindow, cost int64) (domain.QuotaResult, error) {
+			if key != "token:abc" || len(windows) != 2 {
+				t.Fatalf("Unexpected call: %s %+v", key, windows)
+			}
+			result := domain.QuotaResult{Allowed: true, Used: make([]int64, len(windows))}
+			for i, window := range windows {
+				result.Used[i] = used[window.Period]
+				if used[window.Period]+cost > window.Limit {
+					result.Allowed = false
+				}
+			}
+			if result.Allowed {
+				for i, window := range windows {
+					used[window.Period] += cost
+					result.Used[i] = used[window.Period]
+				}
+			}
+			return result, nil
+		},
+		QuotaUsedFunc: func(ctx context.Context, key string, windows []domain.QuotaWindow) ([]int64, error) {
+			return []int64{used[domain.QuotaDaily], used[domain.QuotaMonthly]}, nil
+		},
+	}
+
+	quotaLimiter := NewRedisQuotaLimiter(mockStore, []domain.QuotaRule{
+		{Period: domain.QuotaDaily, Limit: 2},
+		{Period: domain.QuotaMonthly, Limit: 10},
+	}, nil)
+	quotaLimiter.now = func() time.Time { return now }
+
+	decision, err := quotaLimiter.EvaluateQuota(context.Background(), "abc", 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !decision.Allowed || decision.Policy != "token-daily" || decision.Remaining != 1 {
+		t.Fatalf("Expected the daily quota to be the most restrictive, got %+v", decision)
+	}
+
+	quotaLimiter.EvaluateQuota(context.Background(), "abc", 1)
+	decision, _ = quotaLimiter.EvaluateQuota(context.Background(), "abc", 1)
+	if decision.Allowed || decision.Reason != domain.ReasonQuotaExceeded || decision.RetryAfter != 12*time.Hour {
+		t.Fatalf("Expected the daily quota to be exhausted until midnight, got %+v", decision)
+	}
+
+	usage, err := quotaLimiter.Usage(context.Background(), "abc")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(usage) != 2 || usage[0].Used != 2 || usage[0].Remaining != 0 || usage[1].Remaining != 8 {
+		t.Fatalf("Unexpected usage: %+v", usage)
+	}
+	if !usage[1].ResetAt.Equal(time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected the monthly quota to reset on the first of the month, got %v", usage[1].ResetAt)
+	}
+}
+
+func TestRedisQuotaLimiter_OversizedCost(t *testing.T) {
+	rules := []domain.QuotaRule{
+		{Period: domain.QuotaDaily, Limit: 100},
+		{Period: domain.QuotaMonthly, Limit: 1000},
+	}
+	quota := NewRedisQuotaLimiter(&MockQuotaStore{}, rules, time.UTC)
+
+	for _, cost := range []int64{101, math.MaxInt64} {
+		decision, err := quota.EvaluateQuota(context.Background(), "abc", cost)
+		if err != nil {
+			t.Fatalf("Cost %d: oversized costs should be denied without reaching the store, got %v", cost, err)
+		}
+		if decision.Allowed || decision.Reason != domain.ReasonQuotaExceeded || decision.RetryAfter <= 0 {
+			t.Fatalf("Cost %d: expected the quota to deny the request, got %+v", cost, decision)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"go.uber.org/zap"
+)
+
+type RedisSlidingLogLimiter struct {
+	storeBlockManager
+	store  domain.SlidingWindowStore
+	config domain.LimiterConfig
+}
+
+func NewRedisSlidingLogLimiter(store domain.SlidingWindowStore, config domain.LimiterConfig) *RedisSlidingLogLimiter {
+	return &RedisSlidingLogLimiter{
+		storeBlockManager: storeBlockManager{blocks: store},
+		store:             store,
+		config:            config,
+	}
+}
+
+func (r *RedisSlidingLogLimiter) AllowRequest(ctx context.Context, key string, isToken bool) (bool, error) {
+	decision, err := r.Evaluate(ctx, key, isToken)
+	return decision.Allowed, err
+}
+
+func (r *RedisSlidingLogLimiter) Evaluate(ctx context.Context, key string, isToken bool) (domain.Decision, error) {
+	return r.EvaluateCost(ctx, key, isToken, 1)
+}
+
+func (r *RedisSlidingLogLimiter) EvaluateCost(ctx context.Context, key string, isToken bool, cost int64) (domain.Decision, error) {
+	prefixedKey := prefixKey(key, isToken)
+	limit := int64(limitFor(r.config, isToken))
+	if decision, oversized := oversizedDecision(cost, limit, windowFor(r.config, isToken), isToken, time.Now()); oversized {
+		return decision, nil
+	}
+	member := fmt.Sprintf("%d-%d", time.Now().UnixNano(), rand.Int63())
+
+	result, err := r.store.AddToLog(ctx, prefixedKey, member, limit, windowFor(r.config, isToken).Microseconds(), r.config.BlockDuration, cost)
+	if err != nil {
+		logger.Error("Store AddToLog failed", err, zap.String("prefixedKey", prefixedKey))
+		return domain.Decision{}, err
+	}
+	logger.Debug("Store AddToLog result",
+		zap.String("prefixedKey", prefixedKey),
+		zap.Bool("allowed", result.Allowed),
+		zap.Int64("remaining", result.Remaining),
+		zap.Int64("limit", limit),
+	)
+
+	return decisionFromResult(result, limit, windowFor(r.config, isToken), isToken, time.Now()), nil
+}
+
+func (r *RedisSlidingLogLimiter) BlockKey(ctx context.Context, key string, duration int64) error {
+	return r.setBlock(ctx, key, domain.BlockReasonManual, duration)
+}
+
+type RedisSlidingWindowLimiter struct {
+	storeBlockManager
+	store  domain.SlidingWindowStore
+	config domain.LimiterConfig
+}
+
+func NewRedisSlidingWindowLimiter(store domain.SlidingWindowStore, config domain.LimiterConfig) *RedisSlidingWindowLimiter {
+	return &RedisSlidingWindowLimiter{
+		storeBlockManager: storeBlockManager{blocks: store},
+		store:             store,
+		config:            config,
+	}
+}
+
+func (r *RedisSlidingWindowLimiter) AllowRequest(ctx context.Context, key string, isToken bool) (bool, error) {
+	decision, err := r.Evaluate(ctx, key, isToken)
+	return decision.Allowed, err
+}
+
+func (r *RedisSlidingWindowLimiter) Evaluate(ctx context.Context, key string, isToken bool) (domain.Decision, error) {
+	return r.EvaluateCost(ctx, key, isToken, 1)
+}
+
+func (r *RedisSlidingWindowLimiter) EvaluateCost(ctx context.Context, key string, isToken bool, cost int64) (domain.Decision, error) {
+	prefixedKey := prefixKey(key, isToken)
+	limit := int64(limitFor(r.config, isToken))
+	if decision, oversized := oversizedDecision(cost, limit, windowFor(r.config, isToken), isToken, time.Now()); oversized {
+		return decision, nil
+	}
+
+	result, err := r.store.IncrementWindowCounter(ctx, prefixedKey, limit, windowFor(r.config, isToken).Microseconds(), r.config.BlockDuration, cost)
+	if err != nil {
+		logger.Error("Store IncrementWindowCounter failed", err, zap.String("prefixedKey", prefixedKey))
+		return domain.Decision{}, err
+	}
+	logger.Debug("Store IncrementWindowCounter result",
+		zap.String("prefixedKey", prefixedKey),
+		zap.Bool("allowed", result.Allowed),
+		zap.Int64("remaining", result.Remaining),
+		zap.Int64("limit", limit),
+	)
+
+	return decisionFromResult(result, limit, windowFor(r.config, isToken), isToken, time.Now()), nil
+}
+
+func (r *RedisSlidingWindowLimiter) BlockKey(ctx context.Context, key string, duration int64) error {
+	return r.setBlock(ctx, key, domain.BlockReasonManual, duration)
+}
@@ -0,0 +1,54 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func TestRedisSlidingWindowLimiters_AllowRequest(t *testing.T) {
+	members := map[string]bool{}
+	mockStore := &MockSlidingWindowStore{
+		AddToLogFunc: func(ctx context.Context, key, member string, limit, window, blockDuration, cost int64) (domain.StoreResult, error) {
+			if key != "ip:10.0.0.1" || limit != 3 || window != time.Second.Microseconds() || blockDuration != 4 {
+				t.Fatalf("Unexpected log parameters: %s/%d/%d/%d", key, limit, window, blockDuration)
+			}
+			if members[member] {
+				t.Fatalf("Log member %s was reused", member)
+			}
+			members[member] = true
+			return domain.StoreResult{Allowed: int64(len(members)) <= limit, Remaining: max(0, limit-int64(len(members)))}, nil
+		},
+		IncrementWindowCounterFunc: func(ctx context.Context, key string, limit, window, blockDuration, cost int64) (domain.StoreResult, error) {
+			if key != "token:abc" || limit != 6 || window != time.Second.Microseconds() {
+				t.Fatalf("Unexpected counter parameters: %s/%d/%d", key, limit, window)
+			}
+			return domain.StoreResult{Blocked: true, RetryAfter: 4 * time.Second}, nil
+		},
+	}
+
+	config := domain.LimiterConfig{
+		MaxRequests:      3,
+		TokenMaxRequests: 6,
+		BlockDuration:    4,
+	}
+	logLimiter := NewRedisSlidingLogLimiter(mockStore, config)
+	windowLimiter := NewRedisSlidingWindowLimiter(mockStore, config)
+
+	for i, expected := range []bool{true, true, true, false} {
+		allowed, err := logLimiter.AllowRequest(context.Background(), "10.0.0.1", false)
+		if err != nil || allowed != expected {
+			t.Fatalf("Log request %d: expected %v, got %v (%v)", i+1, expected, allowed, err)
+		}
+	}
+
+	decision, err := windowLimiter.Evaluate(context.Background(), "abc", true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decision.Allowed || decision.Reason != domain.ReasonBlocked || decision.RetryAfter != 4*time.Second {
+		t.Fatalf("Expected a blocked window counter decision, got %+v", decision)
+	}
+}
@@ -0,0 +1,59 @@
+package limiter
+
+import (
+	"context"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"go.uber.org/zap"
+)
+
+type RedisStackedLimiter struct {
+	storeBlockManager
+	store  domain.StackedLimitStore
+	config domain.LimiterConfig
+}
+
+func NewRedisStackedLimiter(store domain.StackedLimitStore, config domain.LimiterConfig) *RedisStackedLimiter {
+	return &RedisStackedLimiter{
+		storeBlockManager: storeBlockManager{blocks: store},
+		store:             store,
+		config:            config,
+	}
+}
+
+func (r *RedisStackedLimiter) AllowRequest(ctx context.Context, key string, isToken bool) (bool, error) {
+	decision, err := r.Evaluate(ctx, key, isToken)
+	return decision.Allowed, err
+}
+
+func (r *RedisStackedLimiter) Evaluate(ctx context.Context, key string, isToken bool) (domain.Decision, error) {
+	return r.EvaluateCost(ctx, key, isToken, 1)
+}
+
+func (r *RedisStackedLimiter) EvaluateCost(ctx context.Context, key string, isToken bool, cost int64) (domain.Decision, error) {
+	prefixedKey := prefixKey(key, isToken)
+	rules := rulesFor(r.config, isToken)
+	if result, oversized := oversizedStacked(cost, rules); oversized {
+		return stackedDecision(result, rules, isToken, time.Now()), nil
+	}
+
+	result, err := r.store.IncrementLimits(ctx, prefixedKey, rules, r.config.BlockDuration, cost)
+	if err != nil {
+		logger.Error("Store IncrementLimits failed", err, zap.String("prefixedKey", prefixedKey))
+		return domain.Decision{}, err
+	}
+	logger.Debug("Store IncrementLimits result",
+		zap.String("prefixedKey", prefixedKey),
+		zap.Bool("allowed", result.Allowed),
+		zap.Int("tripped", result.Tripped),
+		zap.Duration("retryAfter", result.RetryAfter),
+	)
+
+	return stackedDecision(result, rules, isToken, time.Now()), nil
+}
+
+func (r *RedisStackedLimiter) BlockKey(ctx context.Context, key string, duration int64) error {
+	return r.setBlock(ctx, key, domain.BlockReasonManual, duration)
+}
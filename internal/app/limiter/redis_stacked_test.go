@@ -0,0 +1,49 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func TestRedisStackedLimiter_Evaluate(t *testing.T) {
+	mockStore := &MockStackedLimitStore{
+		IncrementLimitsFunc: func(ctx context.Context, key string, rules []domain.LimitRule, blockDuration, cost int64) (domain.StackedResult, error) {
+			if key != "token:abc" || len(rules) != 2 || blockDuration != 3 {
+				t.Fatalf("Unexpected call: %s %+v %d", key, rules, blockDuration)
+			}
+			if rules[0].MaxRequests != 10 || rules[1].Window != 24*time.Hour {
+				t.Fatalf("Unexpected rules: %+v", rules)
+			}
+			return domain.StackedResult{
+				Tripped:    1,
+				RetryAfter: time.Hour,
+				Limits: []domain.LimitResult{
+					{Remaining: 9, ResetAfter: time.Second},
+					{Remaining: 0, ResetAfter: time.Hour},
+				},
+			}, nil
+		},
+	}
+
+	redisLimiter := NewRedisStackedLimiter(mockStore, domain.LimiterConfig{
+		BlockDuration: 3,
+		TokenLimits: []domain.LimitRule{
+			{MaxRequests: 10, Window: time.Second},
+			{MaxRequests: 50000, Window: 24 * time.Hour},
+		},
+	})
+
+	decision, err := redisLimiter.Evaluate(context.Background(), "abc", true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decision.Allowed || decision.Policy != "token-1d" || decision.Limit != 50000 || decision.RetryAfter != time.Hour {
+		t.Fatalf("Expected the daily limit to be reported as tripped, got %+v", decision)
+	}
+	if decision.Limits[0].Policy != "token-1s" || decision.Limits[0].Remaining != 9 {
+		t.Fatalf("Unexpected per-second status: %+v", decision.Limits[0])
+	}
+}
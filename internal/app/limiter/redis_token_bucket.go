@@ -0,0 +1,60 @@
+package limiter
+
+import (
+	"context"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"go.uber.org/zap"
+)
+
+type RedisTokenBucketLimiter struct {
+	storeBlockManager
+	store  domain.TokenBucketStore
+	config domain.LimiterConfig
+}
+
+func NewRedisTokenBucketLimiter(store domain.TokenBucketStore, config domain.LimiterConfig) *RedisTokenBucketLimiter {
+	return &RedisTokenBucketLimiter{
+		storeBlockManager: storeBlockManager{blocks: store},
+		store:             store,
+		config:            config,
+	}
+}
+
+func (r *RedisTokenBucketLimiter) AllowRequest(ctx context.Context, key string, isToken bool) (bool, error) {
+	decision, err := r.Evaluate(ctx, key, isToken)
+	return decision.Allowed, err
+}
+
+func (r *RedisTokenBucketLimiter) Evaluate(ctx context.Context, key string, isToken bool) (domain.Decision, error) {
+	return r.EvaluateCost(ctx, key, isToken, 1)
+}
+
+func (r *RedisTokenBucketLimiter) EvaluateCost(ctx context.Context, key string, isToken bool, cost int64) (domain.Decision, error) {
+	prefixedKey := prefixKey(key, isToken)
+	capacity := int64(burstFor(r.config, isToken))
+	refillRate := refillRateFor(r.config, isToken)
+	if decision, oversized := oversizedDecision(cost, capacity, burstWindowFor(r.config, isToken), isToken, time.Now()); oversized {
+		return decision, nil
+	}
+
+	result, err := r.store.TakeToken(ctx, prefixedKey, capacity, refillRate, r.config.BlockDuration, cost)
+	if err != nil {
+		logger.Error("Store TakeToken failed", err, zap.String("prefixedKey", prefixedKey))
+		return domain.Decision{}, err
+	}
+	logger.Debug("Store TakeToken result",
+		zap.String("prefixedKey", prefixedKey),
+		zap.Bool("allowed", result.Allowed),
+		zap.Int64("remaining", result.Remaining),
+		zap.Int64("capacity", capacity),
+	)
+
+	return decisionFromResult(result, capacity, burstWindowFor(r.config, isToken), isToken, time.Now()), nil
+}
+
+func (r *RedisTokenBucketLimiter) BlockKey(ctx context.Context, key string, duration int64) error {
+	return r.setBlock(ctx, key, domain.BlockReasonManual, duration)
+}
@@ -0,0 +1,52 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func TestRedisTokenBucketLimiter_AllowRequest(t *testing.T) {
+	mockStore := &MockTokenBucketStore{
+		TakeTokenFunc: func(ctx context.Context, key string, capacity int64, refillPerSecond float64, blockDuration, cost int64) (domain.StoreResult, error) {
+			switch key {
+			case "ip:10.0.0.1":
+				if capacity != 8 || refillPerSecond != 2 || blockDuration != 5 {
+					t.Fatalf("Unexpected IP bucket parameters: %d/%v/%d", capacity, refillPerSecond, blockDuration)
+				}
+				return domain.StoreResult{Allowed: true, Remaining: 7}, nil
+			case "token:abc":
+				if capacity != 10 || refillPerSecond != 10 {
+					t.Fatalf("Unexpected token bucket parameters: %d/%v", capacity, refillPerSecond)
+				}
+				return domain.StoreResult{RetryAfter: 100 * time.Millisecond}, nil
+			}
+			return domain.StoreResult{}, errors.New("unexpected key " + key)
+		},
+	}
+
+	redisLimiter := NewRedisTokenBucketLimiter(mockStore, domain.LimiterConfig{
+		MaxRequests:      2,
+		TokenMaxRequests: 10,
+		BurstCapacity:    8,
+		BlockDuration:    5,
+	})
+
+	if allowed, err := redisLimiter.AllowRequest(context.Background(), "10.0.0.1", false); err != nil || !allowed {
+		t.Fatalf("Expected IP request to be allowed, got %v (%v)", allowed, err)
+	}
+
+	decision, err := redisLimiter.Evaluate(context.Background(), "abc", true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decision.Allowed || decision.Limit != 10 || decision.RetryAfter != 100*time.Millisecond {
+		t.Fatalf("Unexpected token decision: %+v", decision)
+	}
+	if decision.Policy != domain.PolicyToken || decision.Reason != domain.ReasonLimitExceeded {
+		t.Fatalf("Unexpected token policy/reason: %s/%s", decision.Policy, decision.Reason)
+	}
+}
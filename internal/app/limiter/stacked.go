@@ -0,0 +1,106 @@
+package limiter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func rulesFor(config domain.LimiterConfig, isToken bool) []domain.LimitRule {
+	if isToken && len(config.TokenLimits) > 0 {
+		return config.TokenLimits
+	}
+	if !isToken && len(config.Limits) > 0 {
+		return config.Limits
+	}
+	return []domain.LimitRule{{
+		MaxRequests: int64(limitFor(config, isToken)),
+		Window:      windowFor(config, isToken),
+	}}
+}
+
+func oversizedStacked(cost int64, rules []domain.LimitRule) (domain.StackedResult, bool) {
+	result := domain.StackedResult{Tripped: -1, Limits: make([]domain.LimitResult, len(rules))}
+	for i, rule := range rules {
+		result.Limits[i] = domain.LimitResult{ResetAfter: rule.Window}
+		if cost > rule.MaxRequests && (result.Tripped < 0 || rule.Window > rules[result.Tripped].Window) {
+			result.Tripped = i
+		}
+	}
+	if result.Tripped < 0 {
+		return domain.StackedResult{}, false
+	}
+	result.RetryAfter = rules[result.Tripped].Window
+	return result, true
+}
+
+func limitPolicyFor(rule domain.LimitRule, isToken bool) string {
+	return policyFor(isToken) + "-" + windowLabel(rule.Window)
+}
+
+func windowLabel(window time.Duration) string {
+	units := []struct {
+		suffix string
+		size   time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	}
+	for _, unit := range units {
+		if window >= unit.size && window%unit.size == 0 {
+			return fmt.Sprintf("%d%s", window/unit.size, unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%dms", window.Milliseconds())
+}
+
+func stackedDecision(result domain.StackedResult, rules []domain.LimitRule, isToken bool, now time.Time) domain.Decision {
+	decision := domain.Decision{
+		Allowed:    result.Allowed,
+		RetryAfter: result.RetryAfter,
+		Reason:     reasonFor(result.Allowed, result.Blocked),
+		Limits:     make([]domain.LimitStatus, len(rules)),
+	}
+
+	primary := result.Tripped
+	for i, rule := range rules {
+		status := domain.LimitStatus{
+			Policy: limitPolicyFor(rule, isToken),
+			Limit:  rule.MaxRequests,
+			Window: rule.Window,
+		}
+		if i < len(result.Limits) {
+			status.Remaining = result.Limits[i].Remaining
+			status.ResetAt = now.Add(result.Limits[i].ResetAfter)
+		}
+		decision.Limits[i] = status
+
+		if result.Tripped < 0 && (primary < 0 || moreRestrictive(status, decision.Limits[primary])) {
+			primary = i
+		}
+	}
+
+	if primary >= 0 {
+		status := decision.Limits[primary]
+		decision.Policy = status.Policy
+		decision.Limit = status.Limit
+		decision.Remaining = status.Remaining
+		decision.Window = status.Window
+		decision.ResetAt = status.ResetAt
+	}
+	if result.Blocked {
+		decision.Remaining = 0
+		decision.ResetAt = now.Add(result.RetryAfter)
+	}
+	return decision
+}
+
+func moreRestrictive(candidate, current domain.LimitStatus) bool {
+	if candidate.Remaining != current.Remaining {
+		return candidate.Remaining < current.Remaining
+	}
+	return candidate.ResetAt.After(current.ResetAt)
+}
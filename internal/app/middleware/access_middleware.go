@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+
+	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"go.uber.org/zap"
+)
+
+func AccessListMiddleware(accessList domain.AccessChecker, clientIP *ClientIPResolver, limit func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		limited := limit(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip, err := clientIP.ClientIP(r)
+			if err != nil {
+				limited.ServeHTTP(w, r)
+				return
+			}
+			addr, err := netip.ParseAddr(ip)
+			if err != nil {
+				limited.ServeHTTP(w, r)
+				return
+			}
+
+			switch accessList.Access(addr) {
+			case domain.AccessDeny:
+				logger.Debug("Request denied by access list", zap.String("ip", ip))
+				http.Error(w, "Forbidden", http.StatusForbidden)
+			case domain.AccessAllow:
+				next.ServeHTTP(w, r)
+			default:
+				limited.ServeHTTP(w, r)
+			}
+		})
+	}
+}
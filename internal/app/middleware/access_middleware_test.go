@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/access"
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func TestAccessListMiddleware(t *testing.T) {
+	accessList := access.NewAccessList(
+		[]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		[]netip.Prefix{netip.MustParsePrefix("203.0.113.0/24")},
+	)
+	rateLimiter := limiter.NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 1})
+	handler := AccessListMiddleware(accessList, nil, RateLimiterMiddleware(rateLimiter))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		codes      []int
+		listed     bool
+	}{
+		{name: "Allowlisted", remoteAddr: "10.1.2.3:1234", codes: []int{http.StatusOK, http.StatusOK, http.StatusOK}, listed: true},
+		{name: "Denylisted", remoteAddr: "203.0.113.9:1234", codes: []int{http.StatusForbidden, http.StatusForbidden}, listed: true},
+		{name: "Unlisted", remoteAddr: "192.0.2.1:1234", codes: []int{http.StatusOK, http.StatusTooManyRequests}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, code := range tt.codes {
+				req := httptest.NewRequest("GET", "/", nil)
+				req.RemoteAddr = tt.remoteAddr
+				res := httptest.NewRecorder()
+				handler.ServeHTTP(res, req)
+				if res.Code != code {
+					t.Fatalf("Request %d: expected %d, got %d", i+1, code, res.Code)
+				}
+				if tt.listed && res.Header().Get("X-RateLimit-Limit") != "" {
+					t.Fatalf("Request %d: listed addresses should not be counted", i+1)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"container/list"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/app/apikey"
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+const (
+	UnknownAPIKeyAllow  = "allow"
+	UnknownAPIKeyReject = "reject"
+	UnknownAPIKeyIP     = "ip"
+
+	apiKeyScope = "key"
+
+	maxKeyLimiters = 1024
+)
+
+type LookupError struct {
+	Key RequestKey
+	Err error
+}
+
+func (e *LookupError) Error() string {
+	return "API key lookup failed: " + e.Err.Error()
+}
+
+func (e *LookupError) Unwrap() error {
+	return e.Err
+}
+
+type KeyLimiterFunc func(limits []domain.LimitRule) domain.Limiter
+
+type keyLimiter struct {
+	signature string
+	limiter   domain.WeightedLimiter
+	built     domain.Limiter
+}
+
+// keyLimiters shares one limiter per distinct set of per-key limits, keeping
+// at most maxKeyLimiters of them and closing the least recently used one when
+// a new set arrives.
+type keyLimiters struct {
+	mu       sync.Mutex
+	build    KeyLimiterFunc
+	closers  *limiter.Closers
+	limiters map[string]*list.Element
+	lru      *list.List
+}
+
+// TokenKey returns the key the limiter charges for a client sending token as
+// its API key: the registry hash when keys are registered, otherwise the
+// token itself, namespaced like every unverified token.
+func TokenKey(token string, registered bool) string {
+	if registered {
+		return apikey.HashKey(token)
+	}
+	return namespaced(RequestKey{Value: token, IsToken: true}).Value
+}
+
+func RegisteredKey(registry domain.APIKeyRegistry, unknown string, fallback, keys KeyExtractor) KeyExtractor {
+	return KeyExtractorFunc(func(r *http.Request) (RequestKey, error) {
+		key, err := keys.ExtractKey(r)
+		if err != nil || !key.IsToken || key.Verified {
+			return key, err
+		}
+
+		token := key.Value
+		key.Value = TokenKey(token, true)
+		apiKey, found, err := registry.Lookup(r.Context(), token)
+		if err != nil {
+			return RequestKey{}, &LookupError{Key: key, Err: err}
+		}
+		if found {
+			key.Verified = true
+			key.APIKey = &apiKey
+			if apiKey.Plan != "" {
+				key.Tier = apiKey.Plan
+			}
+			return key, nil
+		}
+
+		switch unknown {
+		case UnknownAPIKeyAllow:
+			return key, nil
+		case UnknownAPIKeyReject:
+			logger.Debug("Rejected unknown API key")
+			return RequestKey{}, &KeyError{Status: http.StatusUnauthorized, Message: "Invalid API key"}
+		}
+		logger.Debug("Limiting unknown API key by IP")
+		return fallback.ExtractKey(r)
+	})
+}
+
+func newKeyLimiters(build KeyLimiterFunc, closers *limiter.Closers) *keyLimiters {
+	return &keyLimiters{build: build, closers: closers, limiters: make(map[string]*list.Element), lru: list.New()}
+}
+
+func (k *keyLimiters) limiterFor(apiKey *domain.APIKey) (domain.WeightedLimiter, bool) {
+	if k.build == nil || apiKey == nil || len(apiKey.Limits) == 0 {
+		return nil, false
+	}
+
+	signature := fmt.Sprint(apiKey.Limits)
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if element, ok := k.limiters[signature]; ok {
+		k.lru.MoveToFront(element)
+		return element.Value.(*keyLimiter).limiter, true
+	}
+
+	if k.lru.Len() >= maxKeyLimiters {
+		oldest := k.lru.Remove(k.lru.Back()).(*keyLimiter)
+		delete(k.limiters, oldest.signature)
+		if err := k.closers.Release(oldest.built); err != nil {
+			logger.Error("Failed to close limiter", err)
+		}
+	}
+	built := k.build(apiKey.Limits)
+	k.closers.Track(built)
+	entry := &keyLimiter{signature: signature, limiter: limiter.NewDecisionAdapter(built), built: built}
+	k.limiters[signature] = k.lru.PushFront(entry)
+	return entry.limiter, true
+}
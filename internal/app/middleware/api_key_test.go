@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/apikey"
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+type failingRegistry struct{}
+
+func (failingRegistry) Lookup(ctx context.Context, token string) (domain.APIKey, bool, error) {
+	return domain.APIKey{}, false, errStoreDown
+}
+
+type fakeRegistry map[string]domain.APIKey
+
+func (f fakeRegistry) Lookup(ctx context.Context, token string) (domain.APIKey, bool, error) {
+	key, ok := f[token]
+	return key, ok, nil
+}
+
+func TestRegisteredKey_UnknownKeys(t *testing.T) {
+	registry := fakeRegistry{"known": {Owner: "acme", Plan: "pro"}}
+
+	tests := []struct {
+		mode     string
+		token    string
+		expected RequestKey
+		status   int
+	}{
+		{mode: UnknownAPIKeyReject, token: "known", expected: RequestKey{Value: apikey.HashKey("known"), IsToken: true, Tier: "pro", Verified: true}},
+		{mode: UnknownAPIKeyAllow, token: "invented", expected: RequestKey{Value: apikey.HashKey("invented"), IsToken: true}},
+		{mode: UnknownAPIKeyIP, token: "invented", expected: RequestKey{Value: "192.0.2.1"}},
+		{mode: "", token: "invented", expected: RequestKey{Value: "192.0.2.1"}},
+		{mode: UnknownAPIKeyReject, token: "invented", status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode+"/"+tt.token, func(t *testing.T) {
+			keys := RegisteredKey(registry, tt.mode, ClientIPKey(nil), DefaultKeyExtractor(nil))
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("API_KEY", tt.token)
+			key, err := keys.ExtractKey(req)
+			if tt.status != 0 {
+				if keyErr, ok := err.(*KeyError); !ok || keyErr.Status != tt.status {
+					t.Fatalf("Expected a %d key error, got %v", tt.status, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			key.APIKey = nil
+			if key != tt.expected {
+				t.Fatalf("Expected %+v, got %+v", tt.expected, key)
+			}
+		})
+	}
+
+	keys := RegisteredKey(registry, UnknownAPIKeyReject, ClientIPKey(nil), DefaultKeyExtractor(nil))
+	if key, err := keys.ExtractKey(httptest.NewRequest("GET", "/", nil)); err != nil || key.Value != "192.0.2.1" {
+		t.Fatalf("Requests without API keys should not be looked up, got %+v (%v)", key, err)
+	}
+}
+
+func TestRateLimiterMiddleware_APIKeyLimits(t *testing.T) {
+	registry := fakeRegistry{
+		"custom": {Owner: "acme", Plan: "pro", Limits: []domain.LimitRule{{MaxRequests: 2, Window: time.Minute}}},
+		"plan":   {Owner: "globex", Plan: "pro"},
+	}
+	tierLimits := []domain.LimitRule{{MaxRequests: 1, Window: time.Minute}}
+	handler := RateLimiterMiddlewareWithConfig(limiter.NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 1, TokenMaxRequests: 100}), MiddlewareConfig{
+		HeaderStyle: HeaderStyleDraft,
+		Keys:        RegisteredKey(registry, UnknownAPIKeyIP, ClientIPKey(nil), DefaultKeyExtractor(nil)),
+		Tiers: []LimitTier{{
+			LimitTier: domain.LimitTier{Name: "pro"},
+			Limiter:   limiter.NewMemoryStackedLimiter(domain.LimiterConfig{Limits: tierLimits, TokenLimits: tierLimits}),
+		}},
+		KeyLimiter: func(limits []domain.LimitRule) domain.Limiter {
+			return limiter.NewMemoryStackedLimiter(domain.LimiterConfig{Limits: limits, TokenLimits: limits})
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("API_KEY", token)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	if res := request("custom"); res.Code != http.StatusOK || res.Header().Get("RateLimit-Policy") != `"key:token-1m";q=2;w=60` {
+		t.Fatalf("Expected the per-key limits to apply, got %d %q", res.Code, res.Header().Get("RateLimit-Policy"))
+	}
+	if res := request("custom"); res.Code != http.StatusOK {
+		t.Fatalf("Expected the per-key limit to allow a second request, got %d", res.Code)
+	}
+	if res := request("custom"); res.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected the per-key limit to be exhausted, got %d", res.Code)
+	}
+
+	if res := request("plan"); res.Code != http.StatusOK || res.Header().Get("RateLimit-Policy") != `"pro:token-1m";q=1;w=60` {
+		t.Fatalf("Expected the plan limits to apply, got %d %q", res.Code, res.Header().Get("RateLimit-Policy"))
+	}
+
+	if res := request("invented-1"); res.Code != http.StatusOK || res.Header().Get("RateLimit-Policy") != `"ip";q=1;w=1` {
+		t.Fatalf("Expected unknown keys to get the IP limit, got %d %q", res.Code, res.Header().Get("RateLimit-Policy"))
+	}
+	if res := request("invented-2"); res.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected invented keys to share the IP budget, got %d", res.Code)
+	}
+}
+
+func TestRateLimiterMiddleware_APIKeyLookupFailure(t *testing.T) {
+	tests := []struct {
+		mode  string
+		codes []int
+	}{
+		{mode: domain.FailureModeOpen, codes: []int{http.StatusOK, http.StatusOK}},
+		{mode: domain.FailureModeClosed, codes: []int{http.StatusServiceUnavailable}},
+		{mode: domain.FailureModeMemory, codes: []int{http.StatusOK, http.StatusTooManyRequests}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			before := degradedCount(tt.mode)
+			var fallbackKeys []string
+			handler := RateLimiterMiddlewareWithConfig(limiter.NewMemoryRateLimiter(domain.LimiterConfig{TokenMaxRequests: 100}), MiddlewareConfig{
+				Keys: RegisteredKey(failingRegistry{}, UnknownAPIKeyReject, ClientIPKey(nil), DefaultKeyExtractor(nil)),
+				Failure: FailurePolicy{
+					Mode: tt.mode,
+					Fallback: recordingLimiter{
+						WeightedLimiter: limiter.NewMemoryRateLimiter(domain.LimiterConfig{TokenMaxRequests: 1, Window: time.Minute}),
+						keys:            &fallbackKeys,
+					},
+				},
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			for i, code := range tt.codes {
+				req := httptest.NewRequest("GET", "/", nil)
+				req.Header.Set("API_KEY", "secret-key")
+				res := httptest.NewRecorder()
+				handler.ServeHTTP(res, req)
+				if res.Code != code {
+					t.Fatalf("Request %d: expected %d, got %d", i+1, code, res.Code)
+				}
+			}
+			if got := degradedCount(tt.mode) - before; got != int64(len(tt.codes)) {
+				t.Fatalf("Expected %d degraded decisions, got %d", len(tt.codes), got)
+			}
+			for _, key := range fallbackKeys {
+				if key != apikey.HashKey("secret-key") {
+					t.Fatalf("Expected the fallback to key on the API key hash, got %q", key)
+				}
+			}
+		})
+	}
+}
+
+type recordingLimiter struct {
+	domain.WeightedLimiter
+	keys *[]string
+}
+
+func (r recordingLimiter) EvaluateCost(ctx context.Context, key string, isToken bool, cost int64) (domain.Decision, error) {
+	*r.keys = append(*r.keys, key)
+	return r.WeightedLimiter.EvaluateCost(ctx, key, isToken, cost)
+}
+
+type closingLimiter struct {
+	*limiter.MemoryRateLimiter
+	closed *int
+}
+
+func (c closingLimiter) Close() error {
+	*c.closed++
+	return c.MemoryRateLimiter.Close()
+}
+
+func TestKeyLimiters_BoundedAndClosed(t *testing.T) {
+	built, closed := 0, 0
+	closers := limiter.NewClosers()
+	keyLimiters := newKeyLimiters(func(limits []domain.LimitRule) domain.Limiter {
+		built++
+		return closingLimiter{MemoryRateLimiter: limiter.NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 1}), closed: &closed}
+	}, closers)
+	apiKey := func(limit int64) *domain.APIKey {
+		return &domain.APIKey{Limits: []domain.LimitRule{{MaxRequests: limit, Window: time.Minute}}}
+	}
+
+	for i := int64(1); i <= maxKeyLimiters+10; i++ {
+		keyLimiters.limiterFor(apiKey(i))
+		keyLimiters.limiterFor(apiKey(1))
+	}
+	if built != maxKeyLimiters+10 || closed != 10 || len(keyLimiters.limiters) != maxKeyLimiters {
+		t.Fatalf("Expected %d limiters with the oldest closed, got %d built, %d closed, %d kept", maxKeyLimiters, built, closed, len(keyLimiters.limiters))
+	}
+	if _, kept := keyLimiters.limiters[fmt.Sprint(apiKey(1).Limits)]; !kept {
+		t.Fatalf("Expected a limiter in use to survive eviction")
+	}
+
+	closers.Close()
+	if closed != maxKeyLimiters+10 {
+		t.Fatalf("Expected shutdown to close every remaining limiter, got %d closed", closed)
+	}
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+const (
+	HeaderForwarded     = "Forwarded"
+	HeaderXForwardedFor = "X-Forwarded-For"
+	HeaderXRealIP       = "X-Real-IP"
+)
+
+var DefaultClientIPHeaders = []string{HeaderXForwardedFor}
+
+var errInvalidHop = errors.New("invalid forwarded hop")
+
+type ClientIPConfig struct {
+	TrustedProxies []netip.Prefix
+	Headers        []string
+	IPv4Prefix     int
+	IPv6Prefix     int
+}
+
+type ClientIPResolver struct {
+	trusted    []netip.Prefix
+	headers    []string
+	ipv4Prefix int
+	ipv6Prefix int
+}
+
+func NewClientIPResolver(config ClientIPConfig) *ClientIPResolver {
+	headers := config.Headers
+	if headers == nil {
+		headers = DefaultClientIPHeaders
+	}
+	canonical := make([]string, len(headers))
+	for i, header := range headers {
+		canonical[i] = http.CanonicalHeaderKey(header)
+	}
+	return &ClientIPResolver{
+		trusted:    config.TrustedProxies,
+		headers:    canonical,
+		ipv4Prefix: prefixBits(config.IPv4Prefix, 32),
+		ipv6Prefix: prefixBits(config.IPv6Prefix, 128),
+	}
+}
+
+func (c *ClientIPResolver) Key(ip string) string {
+	addr, err := parseHop(ip)
+	if err != nil {
+		return ip
+	}
+	if c == nil {
+		return addr.String()
+	}
+
+	bits := c.ipv6Prefix
+	if addr.Is4() {
+		bits = c.ipv4Prefix
+	}
+	if bits == addr.BitLen() {
+		return addr.String()
+	}
+	prefix, _ := addr.Prefix(bits)
+	return prefix.String()
+}
+
+func (c *ClientIPResolver) ClientIP(r *http.Request) (string, error) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "", err
+	}
+	remote, err := parseHop(host)
+	if err != nil {
+		return "", err
+	}
+	if c == nil || !c.isTrusted(remote) {
+		return remote.String(), nil
+	}
+
+	for _, header := range c.headers {
+		hops := forwardedHops(r.Header, header)
+		if len(hops) == 0 {
+			continue
+		}
+		return c.rightmostUntrusted(hops, remote).String(), nil
+	}
+	return remote.String(), nil
+}
+
+func (c *ClientIPResolver) rightmostUntrusted(hops []string, remote netip.Addr) netip.Addr {
+	closest := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := parseHop(hops[i])
+		if err != nil {
+			return closest
+		}
+		if !c.isTrusted(addr) {
+			return addr
+		}
+		closest = addr
+	}
+	return closest
+}
+
+func (c *ClientIPResolver) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range c.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func forwardedHops(header http.Header, name string) []string {
+	var hops []string
+	for _, value := range header.Values(name) {
+		for _, element := range strings.Split(value, ",") {
+			element = strings.TrimSpace(element)
+			if name == HeaderForwarded {
+				element = forwardedFor(element)
+			}
+			hops = append(hops, element)
+		}
+	}
+	return hops
+}
+
+func forwardedFor(element string) string {
+	for _, pair := range strings.Split(element, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if found && strings.EqualFold(strings.TrimSpace(key), "for") {
+			return strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return ""
+}
+
+func prefixBits(bits, bitLen int) int {
+	if bits <= 0 || bits > bitLen {
+		return bitLen
+	}
+	return bits
+}
+
+func parseHop(hop string) (netip.Addr, error) {
+	if addrPort, err := netip.ParseAddrPort(hop); err == nil {
+		return addrPort.Addr().Unmap(), nil
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(hop, "["), "]"))
+	if err != nil || addr.Zone() != "" {
+		return netip.Addr{}, errInvalidHop
+	}
+	return addr.Unmap(), nil
+}
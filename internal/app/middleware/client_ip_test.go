@@ -0,0 +1,305 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8:1::/48"),
+	}
+	resolver := NewClientIPResolver(ClientIPConfig{TrustedProxies: trusted})
+	realIPOnly := NewClientIPResolver(ClientIPConfig{TrustedProxies: trusted, Headers: []string{"x-real-ip"}})
+	forwarded := NewClientIPResolver(ClientIPConfig{TrustedProxies: trusted, Headers: []string{"Forwarded"}})
+	allHeaders := NewClientIPResolver(ClientIPConfig{TrustedProxies: trusted, Headers: []string{"Forwarded", "X-Forwarded-For", "X-Real-IP"}})
+
+	tests := []struct {
+		name     string
+		resolver *ClientIPResolver
+		remote   string
+		headers  map[string][]string
+		expected string
+	}{
+		{
+			name:     "Headers from an untrusted peer are ignored",
+			resolver: resolver,
+			remote:   "203.0.113.9:5000",
+			headers:  map[string][]string{"X-Forwarded-For": {"1.1.1.1"}, "X-Real-Ip": {"2.2.2.2"}, "Forwarded": {"for=3.3.3.3"}},
+			expected: "203.0.113.9",
+		},
+		{
+			name:     "Without a resolver only the peer address is used",
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"X-Forwarded-For": {"1.1.1.1"}},
+			expected: "10.0.0.1",
+		},
+		{
+			name:     "Trusted peer without forwarding headers",
+			resolver: resolver,
+			remote:   "10.0.0.1:5000",
+			expected: "10.0.0.1",
+		},
+		{
+			name:     "Single X-Forwarded-For hop",
+			resolver: resolver,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"X-Forwarded-For": {"1.1.1.1"}},
+			expected: "1.1.1.1",
+		},
+		{
+			name:     "Client-supplied left-most hops are not trusted",
+			resolver: resolver,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"X-Forwarded-For": {"6.6.6.6, 7.7.7.7, 1.1.1.1"}},
+			expected: "1.1.1.1",
+		},
+		{
+			name:     "Trusted proxies in the chain are skipped",
+			resolver: resolver,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"X-Forwarded-For": {"6.6.6.6, 1.1.1.1, 10.0.0.2, 10.0.0.3"}},
+			expected: "1.1.1.1",
+		},
+		{
+			name:     "Repeated X-Forwarded-For headers form one chain",
+			resolver: resolver,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"X-Forwarded-For": {"6.6.6.6", "1.1.1.1, 10.0.0.2"}},
+			expected: "1.1.1.1",
+		},
+		{
+			name:     "A spoofed trusted address on the left is still a trusted hop",
+			resolver: resolver,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"X-Forwarded-For": {"10.9.9.9, 1.1.1.1"}},
+			expected: "1.1.1.1",
+		},
+		{
+			name:     "An all-trusted chain resolves to the furthest hop",
+			resolver: resolver,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}},
+			expected: "10.0.0.3",
+		},
+		{
+			name:     "An invalid hop appended by a proxy stops at the closest trusted hop",
+			resolver: resolver,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"X-Forwarded-For": {"1.1.1.1, 10.0.0.2, not-an-ip"}},
+			expected: "10.0.0.1",
+		},
+		{
+			name:     "Client-supplied garbage on the left is never reached",
+			resolver: resolver,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"X-Forwarded-For": {"<script>, 1.1.1.1"}},
+			expected: "1.1.1.1",
+		},
+		{
+			name:     "Zoned addresses are rejected",
+			resolver: resolver,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"X-Forwarded-For": {"fe80::1%eth0"}},
+			expected: "10.0.0.1",
+		},
+		{
+			name:     "Ports and IPv4-mapped addresses are normalised",
+			resolver: resolver,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"X-Forwarded-For": {"[::ffff:1.1.1.1]:8080"}},
+			expected: "1.1.1.1",
+		},
+		{
+			name:     "IPv4 hop with a port",
+			resolver: resolver,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"X-Forwarded-For": {"1.1.1.1:5555"}},
+			expected: "1.1.1.1",
+		},
+		{
+			name:     "Trusted IPv6 peer",
+			resolver: resolver,
+			remote:   "[2001:db8:1::5]:443",
+			headers:  map[string][]string{"X-Forwarded-For": {"2001:db8:cafe::17"}},
+			expected: "2001:db8:cafe::17",
+		},
+		{
+			name:     "RFC 7239 Forwarded with quoted IPv6 and port",
+			resolver: forwarded,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"Forwarded": {`for=6.6.6.6, for="[2001:db8:cafe::17]:4711"`}},
+			expected: "2001:db8:cafe::17",
+		},
+		{
+			name:     "RFC 7239 Forwarded with other parameters",
+			resolver: forwarded,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"Forwarded": {"proto=https;For=1.1.1.1;by=10.0.0.1, for=10.0.0.2"}},
+			expected: "1.1.1.1",
+		},
+		{
+			name:     "RFC 7239 obfuscated identifiers are not addresses",
+			resolver: forwarded,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"Forwarded": {"for=1.1.1.1, for=unknown"}},
+			expected: "10.0.0.1",
+		},
+		{
+			name:     "Configured headers are consulted in order",
+			resolver: allHeaders,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"Forwarded": {"for=1.1.1.1"}, "X-Forwarded-For": {"2.2.2.2"}},
+			expected: "1.1.1.1",
+		},
+		{
+			name:     "X-Real-IP is used when no other configured header is present",
+			resolver: allHeaders,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"X-Real-Ip": {"3.3.3.3"}},
+			expected: "3.3.3.3",
+		},
+		{
+			name:     "Injected Forwarded and X-Real-IP are ignored by default",
+			resolver: resolver,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"Forwarded": {"for=6.6.6.6"}, "X-Real-Ip": {"7.7.7.7"}, "X-Forwarded-For": {"1.1.1.1"}},
+			expected: "1.1.1.1",
+		},
+		{
+			name:     "Injected Forwarded is ignored when the proxy sets no header",
+			resolver: resolver,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"Forwarded": {"for=6.6.6.6"}, "X-Real-Ip": {"7.7.7.7"}},
+			expected: "10.0.0.1",
+		},
+		{
+			name:     "Only configured headers are honoured",
+			resolver: realIPOnly,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"Forwarded": {"for=1.1.1.1"}, "X-Forwarded-For": {"2.2.2.2"}, "X-Real-Ip": {"3.3.3.3"}},
+			expected: "3.3.3.3",
+		},
+		{
+			name:     "Unconfigured headers cannot be used to spoof",
+			resolver: realIPOnly,
+			remote:   "10.0.0.1:5000",
+			headers:  map[string][]string{"X-Forwarded-For": {"2.2.2.2"}},
+			expected: "10.0.0.1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = test.remote
+			for name, values := range test.headers {
+				for _, value := range values {
+					req.Header.Add(name, value)
+				}
+			}
+
+			ip, err := test.resolver.ClientIP(req)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if ip != test.expected {
+				t.Fatalf("Expected %s, got %s", test.expected, ip)
+			}
+		})
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "not-an-address"
+	if _, err := resolver.ClientIP(req); err == nil {
+		t.Fatalf("Expected an error for an unparseable peer address")
+	}
+}
+
+func TestClientIPResolver_Key(t *testing.T) {
+	aggregated := NewClientIPResolver(ClientIPConfig{IPv4Prefix: 24, IPv6Prefix: 64})
+	exact := NewClientIPResolver(ClientIPConfig{})
+
+	tests := []struct {
+		resolver *ClientIPResolver
+		ip       string
+		expected string
+	}{
+		{resolver: aggregated, ip: "2001:db8:1:2:aaaa::1", expected: "2001:db8:1:2::/64"},
+		{resolver: aggregated, ip: "2001:DB8:1:2:ffff:ffff:ffff:ffff", expected: "2001:db8:1:2::/64"},
+		{resolver: aggregated, ip: "192.168.1.77", expected: "192.168.1.0/24"},
+		{resolver: aggregated, ip: "::ffff:192.168.1.77", expected: "192.168.1.0/24"},
+		{resolver: aggregated, ip: "[2001:db8:1:2::9]:443", expected: "2001:db8:1:2::/64"},
+		{resolver: exact, ip: "2001:0db8:0000:0000:0000:0000:0000:0001", expected: "2001:db8::1"},
+		{resolver: exact, ip: "::ffff:10.0.0.1", expected: "10.0.0.1"},
+		{resolver: exact, ip: "192.168.1.1:12345", expected: "192.168.1.1"},
+		{ip: "2001:db8::1", expected: "2001:db8::1"},
+		{resolver: aggregated, ip: "not-an-ip", expected: "not-an-ip"},
+	}
+
+	for _, test := range tests {
+		if key := test.resolver.Key(test.ip); key != test.expected {
+			t.Fatalf("Key(%q): expected %s, got %s", test.ip, test.expected, key)
+		}
+	}
+}
+
+func TestRateLimiterMiddleware_IPv6Aggregation(t *testing.T) {
+	rateLimiter := limiter.NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 2})
+	handler := RateLimiterMiddlewareWithConfig(rateLimiter, MiddlewareConfig{
+		HeaderStyle: HeaderStyleNone,
+		ClientIP:    NewClientIPResolver(ClientIPConfig{IPv6Prefix: 64}),
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i, remote := range []string{"[2001:db8:1:2::1]:5000", "[2001:db8:1:2::2]:5000", "[2001:db8:1:2:dead:beef::3]:5000"} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remote
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		if expected := []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}[i]; res.Code != expected {
+			t.Fatalf("Request from %s: expected %d, got %d", remote, expected, res.Code)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "[2001:db8:1:3::1]:5000"
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("Other /64 networks should have their own bucket, got %d", res.Code)
+	}
+}
+
+func TestRateLimiterMiddleware_ClientIP(t *testing.T) {
+	rateLimiter := limiter.NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 1})
+	handler := RateLimiterMiddlewareWithConfig(rateLimiter, MiddlewareConfig{
+		HeaderStyle: HeaderStyleNone,
+		ClientIP:    NewClientIPResolver(ClientIPConfig{TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.1/32")}}),
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(forwardedFor string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "10.0.0.1:5000"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	if request("1.1.1.1") != http.StatusOK || request("2.2.2.2") != http.StatusOK {
+		t.Fatalf("Clients behind the load balancer should have separate buckets")
+	}
+	if code := request("9.9.9.9, 1.1.1.1"); code != http.StatusTooManyRequests {
+		t.Fatalf("Spoofing X-Forwarded-For should not reset the bucket, got %d", code)
+	}
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"go.uber.org/zap"
+)
+
+func ConcurrencyLimiterMiddleware(concurrencyLimiter domain.ConcurrencyLimiter, keys KeyExtractor, failureMode string) func(http.Handler) http.Handler {
+	failure := failureHandler{mode: failureMode}
+	if keys == nil {
+		keys = DefaultKeyExtractor(nil)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestKey, lookupErr, ok := extractKey(w, r, keys)
+			if !ok {
+				return
+			}
+			key, isToken := requestKey.Value, requestKey.IsToken
+
+			var decision domain.Decision
+			var lease domain.Lease
+			err := lookupErr
+			if err == nil {
+				decision, lease, err = concurrencyLimiter.Acquire(r.Context(), key, isToken)
+			}
+			if err != nil {
+				if requestCanceled(r, key, err) {
+					return
+				}
+				if !failure.bypass("concurrency", key, err) {
+					serviceUnavailable(w)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !decision.Allowed {
+				logger.Debug("Request denied",
+					zap.String("key", key),
+					zap.String("policy", decision.Policy),
+					zap.String("reason", decision.Reason),
+				)
+				w.Header().Set("Retry-After", strconv.FormatInt(max(1, ceilSeconds(decision.RetryAfter)), 10))
+				http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+				return
+			}
+
+			stopRenewing := renewLease(context.WithoutCancel(r.Context()), concurrencyLimiter, lease, key)
+			defer func() {
+				stopRenewing()
+				if err := concurrencyLimiter.Release(context.WithoutCancel(r.Context()), lease); err != nil {
+					logger.Error("Concurrency slot release failed", err, zap.String("key", key))
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// renewLease extends the lease every third of its duration while the handler
+// runs, so a request that outlives CONCURRENCY_LEASE_SECONDS keeps its slot.
+// Leases still expire on their own when the instance dies. The returned
+// function stops the renewal.
+func renewLease(ctx context.Context, concurrencyLimiter domain.ConcurrencyLimiter, lease domain.Lease, key string) func() {
+	if lease.ID == "" || lease.Duration <= 0 {
+		return func() {}
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(lease.Duration / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				err := concurrencyLimiter.Renew(ctx, lease)
+				if err == nil {
+					continue
+				}
+				logger.Error("Concurrency lease renewal failed", err, zap.String("key", key))
+				if errors.Is(err, limiter.ErrLeaseExpired) {
+					return
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+	}
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+func TestConcurrencyLimiterMiddleware(t *testing.T) {
+	concurrencyLimiter := limiter.NewMemoryConcurrencyLimiter(domain.LimiterConfig{TokenMaxConcurrent: 2})
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	handler := ConcurrencyLimiterMiddleware(concurrencyLimiter, nil, domain.FailureModeClosed)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/export" {
+			started <- struct{}{}
+			<-finish
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("API_KEY", "abc")
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if res := request("/export"); res.Code != http.StatusOK {
+				t.Errorf("Slow request should have been allowed, got %d", res.Code)
+			}
+		}()
+		<-started
+	}
+
+	res := request("/")
+	if res.Code != http.StatusTooManyRequests || res.Header().Get("Retry-After") != "1" {
+		t.Fatalf("Expected a 429 while both slots are in use, got %d (Retry-After %q)", res.Code, res.Header().Get("Retry-After"))
+	}
+
+	close(finish)
+	wg.Wait()
+
+	if res := request("/"); res.Code != http.StatusOK {
+		t.Fatalf("Slots should be released when the handler finishes, got %d", res.Code)
+	}
+}
+
+type releaseRecorder struct {
+	domain.ConcurrencyLimiter
+	released chan error
+}
+
+func (r releaseRecorder) Release(ctx context.Context, lease domain.Lease) error {
+	r.released <- ctx.Err()
+	return r.ConcurrencyLimiter.Release(ctx, lease)
+}
+
+func TestConcurrencyLimiterMiddleware_ReleaseAfterCancel(t *testing.T) {
+	recorder := releaseRecorder{
+		ConcurrencyLimiter: limiter.NewMemoryConcurrencyLimiter(domain.LimiterConfig{MaxConcurrent: 1}),
+		released:           make(chan error, 1),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := ConcurrencyLimiterMiddleware(recorder, nil, domain.FailureModeClosed)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	if err := <-recorder.released; err != nil {
+		t.Fatalf("Slots should be released even after the client goes away, got %v", err)
+	}
+}
+
+func TestConcurrencyLimiterMiddleware_RenewsLongRequests(t *testing.T) {
+	concurrencyLimiter := limiter.NewMemoryConcurrencyLimiter(domain.LimiterConfig{MaxConcurrent: 1, LeaseDuration: 60 * time.Millisecond})
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	handler := ConcurrencyLimiterMiddleware(concurrencyLimiter, nil, domain.FailureModeClosed)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/export" {
+			close(started)
+			<-finish
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/export", nil))
+	}()
+	<-started
+
+	time.Sleep(200 * time.Millisecond)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+	if res.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected a request outliving its lease to keep the slot, got %d", res.Code)
+	}
+
+	close(finish)
+	<-done
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("Expected the slot to be released once the handler finished, got %d", res.Code)
+	}
+}
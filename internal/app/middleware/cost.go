@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type CostFunc func(r *http.Request) int64
+
+func StaticCost(cost int64) CostFunc {
+	return func(*http.Request) int64 {
+		return cost
+	}
+}
+
+func QueryCost(param string, defaultCost int64) CostFunc {
+	return func(r *http.Request) int64 {
+		cost, err := strconv.ParseInt(r.URL.Query().Get(param), 10, 64)
+		if err != nil || cost < 1 {
+			return defaultCost
+		}
+		return cost
+	}
+}
+
+func BodyCost(bytesPerUnit int64) CostFunc {
+	return func(r *http.Request) int64 {
+		if r.ContentLength <= 0 || bytesPerUnit <= 0 {
+			return 1
+		}
+		return (r.ContentLength + bytesPerUnit - 1) / bytesPerUnit
+	}
+}
+
+// RouteCosts matches the chi route pattern (e.g. /orders/{id}), like route
+// policies do, falling back to the raw path outside a chi router.
+func RouteCosts(routes map[string]CostFunc, fallback CostFunc) CostFunc {
+	return func(r *http.Request) int64 {
+		pattern := routePattern(r)
+		if cost, ok := routes[r.Method+" "+pattern]; ok {
+			return cost(r)
+		}
+		if cost, ok := routes[pattern]; ok {
+			return cost(r)
+		}
+		if fallback != nil {
+			return fallback(r)
+		}
+		return 1
+	}
+}
+
+func ParseCost(spec string) (CostFunc, error) {
+	spec = strings.TrimSpace(spec)
+	if param, found := strings.CutPrefix(spec, "query:"); found && param != "" {
+		return QueryCost(param, 1), nil
+	}
+	if size, found := strings.CutPrefix(spec, "body:"); found {
+		bytesPerUnit, err := strconv.ParseInt(size, 10, 64)
+		if err != nil || bytesPerUnit < 1 {
+			return nil, fmt.Errorf("invalid cost %q: body size must be a positive integer", spec)
+		}
+		return BodyCost(bytesPerUnit), nil
+	}
+
+	cost, err := strconv.ParseInt(spec, 10, 64)
+	if err != nil || cost < 1 {
+		return nil, fmt.Errorf("invalid cost %q: expected a positive integer, query:<param> or body:<bytes>", spec)
+	}
+	return StaticCost(cost), nil
+}
+
+func ParseRouteCosts(specs map[string]string) (CostFunc, error) {
+	routes := make(map[string]CostFunc, len(specs))
+	for route, spec := range specs {
+		cost, err := ParseCost(spec)
+		if err != nil {
+			return nil, fmt.Errorf("route %q: %w", route, err)
+		}
+		routes[route] = cost
+	}
+	return RouteCosts(routes, nil), nil
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestCostFuncs(t *testing.T) {
+	costFunc, err := ParseRouteCosts(map[string]string{
+		"GET /export": "10",
+		"/export":     "2",
+		"/search":     "query:page_size",
+		"/upload":     "body:1024",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		method   string
+		target   string
+		body     string
+		expected int64
+	}{
+		{method: "GET", target: "/export", expected: 10},
+		{method: "POST", target: "/export", expected: 2},
+		{method: "GET", target: "/search?page_size=50", expected: 50},
+		{method: "GET", target: "/search?page_size=abc", expected: 1},
+		{method: "POST", target: "/upload", body: strings.Repeat("x", 2500), expected: 3},
+		{method: "POST", target: "/upload", expected: 1},
+		{method: "GET", target: "/", expected: 1},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+		if cost := costFunc(req); cost != test.expected {
+			t.Fatalf("%s %s: expected cost %d, got %d", test.method, test.target, test.expected, cost)
+		}
+	}
+
+	for _, spec := range []string{"0", "abc", "body:0", "query:"} {
+		if _, err := ParseCost(spec); err == nil {
+			t.Fatalf("ParseCost(%q): expected an error", spec)
+		}
+	}
+}
+
+func TestRouteCosts_ChiPatterns(t *testing.T) {
+	costFunc, err := ParseRouteCosts(map[string]string{
+		"POST /orders/{id}": "5",
+		"/files/*":          "3",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var cost int64
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cost = costFunc(r)
+			next.ServeHTTP(w, r)
+		})
+	})
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router.Post("/orders/{id}", ok)
+	router.Get("/orders/{id}", ok)
+	router.Get("/files/*", ok)
+
+	tests := []struct {
+		method   string
+		target   string
+		expected int64
+	}{
+		{method: "POST", target: "/orders/42", expected: 5},
+		{method: "GET", target: "/orders/42", expected: 1},
+		{method: "GET", target: "/files/a/b.txt", expected: 3},
+	}
+	for _, test := range tests {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(test.method, test.target, nil))
+		if cost != test.expected {
+			t.Fatalf("%s %s: expected cost %d, got %d", test.method, test.target, test.expected, cost)
+		}
+	}
+}
+
+func TestRateLimiterMiddleware_Cost(t *testing.T) {
+	rateLimiter := limiter.NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 10, Window: time.Minute})
+	handler := RateLimiterMiddlewareWithConfig(rateLimiter, MiddlewareConfig{
+		HeaderStyle: HeaderStyleLegacy,
+		Cost:        RouteCosts(map[string]CostFunc{"/export": StaticCost(4)}, nil),
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	steps := []struct {
+		path      string
+		status    int
+		remaining string
+	}{
+		{path: "/export", status: http.StatusOK, remaining: "6"},
+		{path: "/export", status: http.StatusOK, remaining: "2"},
+		{path: "/export", status: http.StatusTooManyRequests, remaining: "0"},
+		{path: "/", status: http.StatusOK, remaining: "1"},
+	}
+	for i, step := range steps {
+		req := httptest.NewRequest("GET", step.path, nil)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		if res.Code != step.status {
+			t.Fatalf("Request %d to %s: expected %d, got %d", i+1, step.path, step.status, res.Code)
+		}
+		if got := res.Header().Get("X-RateLimit-Remaining"); got != step.remaining {
+			t.Fatalf("Request %d to %s: expected remaining %s, got %q", i+1, step.path, step.remaining, got)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"net/http"
+
+	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"go.uber.org/zap"
+)
+
+var DegradedDecisions = expvar.NewMap("rate_limiter_degraded_decisions")
+
+type FailurePolicy struct {
+	Mode     string
+	Fallback domain.Limiter
+}
+
+type failureHandler struct {
+	mode     string
+	fallback domain.WeightedLimiter
+}
+
+func newFailureHandler(policy FailurePolicy) failureHandler {
+	handler := failureHandler{mode: policy.Mode}
+	if policy.Fallback != nil {
+		handler.fallback = limiter.NewDecisionAdapter(policy.Fallback)
+	}
+	return handler
+}
+
+// withFallback swaps in the scaled copy of a scope's own limits, so a tier,
+// per-key or route policy keeps its shape while the store is down.
+func (f failureHandler) withFallback(fallback domain.WeightedLimiter) failureHandler {
+	if fallback != nil {
+		f.fallback = fallback
+	}
+	return f
+}
+
+func (f failureHandler) degrade(ctx context.Context, scope, key string, isToken bool, cost int64, cause error) (domain.Decision, string) {
+	decision := domain.Decision{Allowed: true, Reason: domain.ReasonDegraded}
+	mode := f.mode
+	if mode == domain.FailureModeMemory {
+		var err error
+		if f.fallback == nil {
+			mode = domain.FailureModeClosed
+		} else if decision, err = f.fallback.EvaluateCost(ctx, key, isToken, cost); err != nil {
+			logger.Error("Fallback limiter error", err, zap.String("key", key))
+			mode = domain.FailureModeClosed
+		}
+	}
+	return decision, recordDegraded(mode, scope, key, cause)
+}
+
+func (f failureHandler) bypass(scope, key string, cause error) bool {
+	mode := f.mode
+	if mode == domain.FailureModeMemory {
+		mode = domain.FailureModeOpen
+	}
+	return recordDegraded(mode, scope, key, cause) == domain.FailureModeOpen
+}
+
+func recordDegraded(mode, scope, key string, cause error) string {
+	if !domain.ValidFailureMode(mode) {
+		mode = domain.FailureModeClosed
+	}
+	DegradedDecisions.Add(mode, 1)
+	logger.Error("Store error, degrading decision", cause,
+		zap.String("key", key),
+		zap.String("scope", scope),
+		zap.String("failureMode", mode),
+	)
+	return mode
+}
+
+func requestCanceled(r *http.Request, key string, cause error) bool {
+	if !errors.Is(r.Context().Err(), context.Canceled) {
+		return false
+	}
+	logger.Debug("Request canceled before the limiter answered", zap.String("key", key), zap.Error(cause))
+	return true
+}
+
+func serviceUnavailable(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", "1")
+	http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+}
@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+var errStoreDown = errors.New("redis: connection refused")
+
+type failingLimiter struct{}
+
+func (failingLimiter) AllowRequest(ctx context.Context, key string, isToken bool) (bool, error) {
+	return false, errStoreDown
+}
+
+func (failingLimiter) BlockKey(ctx context.Context, key string, duration int64) error {
+	return errStoreDown
+}
+
+type failingQuota struct{}
+
+func (failingQuota) EvaluateQuota(ctx context.Context, token string, cost int64) (domain.Decision, error) {
+	return domain.Decision{}, errStoreDown
+}
+
+func (failingQuota) Usage(ctx context.Context, token string) ([]domain.QuotaUsage, error) {
+	return nil, errStoreDown
+}
+
+func TestRateLimiterMiddleware_FailureModes(t *testing.T) {
+	tests := []struct {
+		mode  string
+		codes []int
+	}{
+		{mode: domain.FailureModeOpen, codes: []int{http.StatusOK, http.StatusOK, http.StatusOK}},
+		{mode: domain.FailureModeClosed, codes: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}},
+		{mode: domain.FailureModeMemory, codes: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			before := degradedCount(tt.mode)
+			handler := RateLimiterMiddlewareWithConfig(failingLimiter{}, MiddlewareConfig{
+				HeaderStyle: HeaderStyleBoth,
+				Quota:       failingQuota{},
+				Failure: FailurePolicy{
+					Mode:     tt.mode,
+					Fallback: limiter.NewMemoryRateLimiter(domain.LimiterConfig{TokenMaxRequests: 2, Window: time.Minute}),
+				},
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			for i, code := range tt.codes {
+				req := httptest.NewRequest("GET", "/", nil)
+				req.Header.Set("API_KEY", "abc")
+				res := httptest.NewRecorder()
+				handler.ServeHTTP(res, req)
+				if res.Code != code {
+					t.Fatalf("Request %d: expected %d, got %d", i+1, code, res.Code)
+				}
+				if code == http.StatusServiceUnavailable && res.Header().Get("Retry-After") == "" {
+					t.Fatalf("Request %d: expected Retry-After on a closed failure", i+1)
+				}
+			}
+			if got := degradedCount(tt.mode) - before; got < int64(len(tt.codes)) {
+				t.Fatalf("Expected every degraded decision to be counted, got %d", got)
+			}
+		})
+	}
+}
+
+func TestRateLimiterMiddleware_PolicyFailureMode(t *testing.T) {
+	policies := []RoutePolicy{
+		{RoutePolicy: domain.RoutePolicy{Name: "/health", Pattern: "/health", FailureMode: domain.FailureModeOpen}},
+	}
+	handler := RateLimiterMiddlewareWithConfig(failingLimiter{}, MiddlewareConfig{
+		Policies: policies,
+		Failure:  FailurePolicy{Mode: domain.FailureModeClosed},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(path string) int {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+		return res.Code
+	}
+	if code := request("/health"); code != http.StatusOK {
+		t.Fatalf("Expected the route to fail open, got %d", code)
+	}
+	if code := request("/orders"); code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected other routes to fail closed, got %d", code)
+	}
+}
+
+func TestRateLimiterMiddleware_ScopedFallbacks(t *testing.T) {
+	fallback := func(limits []domain.LimitRule) domain.Limiter {
+		return limiter.NewMemoryStackedLimiter(domain.LimiterConfig{Limits: limits, TokenLimits: limits})
+	}
+	perMinute := func(limit int64) []domain.LimitRule {
+		return []domain.LimitRule{{MaxRequests: limit, Window: time.Minute}}
+	}
+	registry := fakeRegistry{
+		"custom": {Owner: "acme", Limits: perMinute(2)},
+		"plan":   {Owner: "globex", Plan: "pro"},
+	}
+	handler := RateLimiterMiddlewareWithConfig(failingLimiter{}, MiddlewareConfig{
+		HeaderStyle: HeaderStyleDraft,
+		Keys:        RegisteredKey(registry, UnknownAPIKeyIP, ClientIPKey(nil), DefaultKeyExtractor(nil)),
+		Policies: []RoutePolicy{{
+			RoutePolicy: domain.RoutePolicy{Name: "post/login", Method: "POST", Pattern: "/login"},
+			Limiter:     failingLimiter{},
+			Fallback:    fallback(perMinute(1)),
+		}},
+		Tiers:       []LimitTier{{LimitTier: domain.LimitTier{Name: "pro"}, Limiter: failingLimiter{}, Fallback: fallback(perMinute(1))}},
+		KeyLimiter:  func([]domain.LimitRule) domain.Limiter { return failingLimiter{} },
+		KeyFallback: fallback,
+		Failure: FailurePolicy{
+			Mode:     domain.FailureModeMemory,
+			Fallback: limiter.NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 100, TokenMaxRequests: 100, Window: time.Minute}),
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("API_KEY", token)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		policy string
+		codes  []int
+	}{
+		{name: "tier", method: "GET", path: "/", token: "plan", policy: `"pro:token-1m";q=1;w=60`, codes: []int{http.StatusOK, http.StatusTooManyRequests}},
+		{name: "per-key", method: "GET", path: "/", token: "custom", policy: `"key:token-1m";q=2;w=60`, codes: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}},
+		{name: "route policy", method: "POST", path: "/login", policy: `"post/login:ip-1m";q=1;w=60`, codes: []int{http.StatusOK, http.StatusTooManyRequests}},
+		{name: "global", method: "GET", path: "/", policy: `"ip";q=100;w=60`, codes: []int{http.StatusOK, http.StatusOK, http.StatusOK}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, code := range tt.codes {
+				res := request(tt.method, tt.path, tt.token)
+				if res.Code != code {
+					t.Fatalf("Request %d: expected %d, got %d", i+1, code, res.Code)
+				}
+				if i == 0 && res.Header().Get("RateLimit-Policy") != tt.policy {
+					t.Fatalf("Expected the scope's own scaled limits %q, got %q", tt.policy, res.Header().Get("RateLimit-Policy"))
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterMiddleware_MemoryFallbackWithoutLimiter(t *testing.T) {
+	handler := RateLimiterMiddlewareWithConfig(failingLimiter{}, MiddlewareConfig{
+		Failure: FailurePolicy{Mode: domain.FailureModeMemory},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+	if res.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected a missing fallback to fail closed, got %d", res.Code)
+	}
+}
+
+type contextLimiter struct{}
+
+func (contextLimiter) AllowRequest(ctx context.Context, key string, isToken bool) (bool, error) {
+	return ctx.Err() == nil, ctx.Err()
+}
+
+func (contextLimiter) BlockKey(ctx context.Context, key string, duration int64) error {
+	return ctx.Err()
+}
+
+func TestRateLimiterMiddleware_CanceledRequest(t *testing.T) {
+	before := degradedCount(domain.FailureModeClosed)
+	handler := RateLimiterMiddlewareWithConfig(contextLimiter{}, MiddlewareConfig{
+		Failure: FailurePolicy{Mode: domain.FailureModeClosed},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("A canceled request should not reach the handler")
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+
+	if res.Body.Len() != 0 {
+		t.Fatalf("Expected no response for a canceled request, got %q", res.Body.String())
+	}
+	if degradedCount(domain.FailureModeClosed) != before {
+		t.Fatalf("A canceled request should not count as a degraded decision")
+	}
+}
+
+func degradedCount(mode string) int64 {
+	if value, ok := DegradedDecisions.Get(mode).(interface{ Value() int64 }); ok {
+		return value.Value()
+	}
+	return 0
+}
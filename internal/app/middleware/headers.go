@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+const (
+	HeaderStyleLegacy = "legacy"
+	HeaderStyleDraft  = "draft"
+	HeaderStyleBoth   = "both"
+	HeaderStyleNone   = "none"
+)
+
+func writeRateLimitHeaders(w http.ResponseWriter, decision domain.Decision, style string, now time.Time) {
+	header := w.Header()
+
+	if !decision.Allowed {
+		header.Set("Retry-After", strconv.FormatInt(max(1, ceilSeconds(decision.RetryAfter)), 10))
+	}
+
+	if decision.Limit <= 0 {
+		return
+	}
+
+	resetIn := int64(0)
+	if !decision.ResetAt.IsZero() {
+		resetIn = max(0, ceilSeconds(decision.ResetAt.Sub(now)))
+	}
+
+	if style == HeaderStyleLegacy || style == HeaderStyleBoth {
+		header.Set("X-RateLimit-Limit", strconv.FormatInt(decision.Limit, 10))
+		header.Set("X-RateLimit-Remaining", strconv.FormatInt(decision.Remaining, 10))
+		header.Set("X-RateLimit-Reset", strconv.FormatInt(now.Unix()+resetIn, 10))
+	}
+
+	if style == HeaderStyleDraft || style == HeaderStyleBoth {
+		policy := decision.Policy
+		if policy == "" {
+			policy = "default"
+		}
+		policies := []string{fmt.Sprintf("%q;q=%d;w=%d", policy, decision.Limit, max(1, ceilSeconds(decision.Window)))}
+		if len(decision.Limits) > 1 {
+			policies = policies[:0]
+			for _, limit := range decision.Limits {
+				policies = append(policies, fmt.Sprintf("%q;q=%d;w=%d", limit.Policy, limit.Limit, max(1, ceilSeconds(limit.Window))))
+			}
+		}
+		header.Set("RateLimit-Policy", strings.Join(policies, ", "))
+		header.Set("RateLimit", fmt.Sprintf("%q;r=%d;t=%d", policy, decision.Remaining, resetIn))
+	}
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/app/auth"
+	"go.uber.org/zap"
+)
+
+var DefaultJWTKeyClaims = []string{"sub"}
+
+type TokenVerifier interface {
+	Verify(token string) (auth.Claims, error)
+}
+
+type JWTKeyConfig struct {
+	Verifier  TokenVerifier
+	KeyClaims []string
+	TierClaim string
+}
+
+func JWTClaimKey(config JWTKeyConfig) KeyExtractor {
+	keyClaims := config.KeyClaims
+	if len(keyClaims) == 0 {
+		keyClaims = DefaultJWTKeyClaims
+	}
+
+	return KeyExtractorFunc(func(r *http.Request) (RequestKey, error) {
+		token, ok := bearerToken(r)
+		if !ok {
+			return RequestKey{}, ErrKeyNotFound
+		}
+
+		claims, err := config.Verifier.Verify(token)
+		if err != nil {
+			logger.Debug("Rejected bearer token", zap.Error(err))
+			return RequestKey{}, &KeyError{Status: http.StatusUnauthorized, Message: "Invalid token"}
+		}
+
+		values := make([]string, len(keyClaims))
+		for i, claim := range keyClaims {
+			if values[i] = claims.String(claim); values[i] == "" {
+				return RequestKey{}, &KeyError{
+					Status:  http.StatusUnauthorized,
+					Message: fmt.Sprintf("Missing %s claim in token", claim),
+				}
+			}
+		}
+
+		key := RequestKey{Value: jwtKeyPrefix + joinKeyParts(values), IsToken: true, Verified: true}
+		if config.TierClaim != "" {
+			key.Tier = claims.String(config.TierClaim)
+		}
+		return key, nil
+	})
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/auth"
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+type fakeVerifier map[string]auth.Claims
+
+func (f fakeVerifier) Verify(token string) (auth.Claims, error) {
+	claims, ok := f[token]
+	if !ok {
+		return nil, auth.ErrInvalidSignature
+	}
+	return claims, nil
+}
+
+func TestJWTClaimKey(t *testing.T) {
+	keys := FirstKey(JWTClaimKey(JWTKeyConfig{
+		Verifier: fakeVerifier{
+			"free-token": {"sub": "user-1", "tenant": "acme", "plan": "free"},
+			"no-tenant":  {"sub": "user-2"},
+		},
+		KeyClaims: []string{"tenant", "sub"},
+		TierClaim: "plan",
+	}), DefaultKeyExtractor(nil))
+
+	extract := func(authorization string) (RequestKey, error) {
+		req := httptest.NewRequest("GET", "/", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		return keys.ExtractKey(req)
+	}
+
+	if key, err := extract("bearer free-token"); err != nil || key != (RequestKey{Value: "jwt:acme:user-1", IsToken: true, Tier: "free", Verified: true}) {
+		t.Fatalf("Unexpected key %+v (%v)", key, err)
+	}
+	if key, err := extract(""); err != nil || key != (RequestKey{Value: "192.0.2.1"}) {
+		t.Fatalf("Requests without a bearer token should fall back, got %+v (%v)", key, err)
+	}
+	if key, err := extract("Basic dXNlcjpwYXNz"); err != nil || key.IsToken {
+		t.Fatalf("Other schemes should fall back, got %+v (%v)", key, err)
+	}
+
+	var keyErr *KeyError
+	for _, authorization := range []string{"Bearer forged", "Bearer no-tenant"} {
+		if _, err := extract(authorization); !errors.As(err, &keyErr) || keyErr.Status != http.StatusUnauthorized {
+			t.Fatalf("%q: expected a 401 key error, got %v", authorization, err)
+		}
+	}
+}
+
+func TestRateLimiterMiddleware_Tiers(t *testing.T) {
+	claims := auth.Claims{"sub": "user-1", "plan": "free"}
+	verifier := fakeVerifier{"token": claims}
+	tierLimits := func(requests int64) domain.LimiterConfig {
+		limits := []domain.LimitRule{{MaxRequests: requests, Window: time.Minute}}
+		return domain.LimiterConfig{Limits: limits, TokenLimits: limits}
+	}
+	handler := RateLimiterMiddlewareWithConfig(limiter.NewMemoryRateLimiter(domain.LimiterConfig{TokenMaxRequests: 100}), MiddlewareConfig{
+		HeaderStyle: HeaderStyleDraft,
+		Keys:        JWTClaimKey(JWTKeyConfig{Verifier: verifier, TierClaim: "plan"}),
+		Tiers: []LimitTier{
+			{LimitTier: domain.LimitTier{Name: "free"}, Limiter: limiter.NewMemoryStackedLimiter(tierLimits(1))},
+			{LimitTier: domain.LimitTier{Name: "pro"}, Limiter: limiter.NewMemoryStackedLimiter(tierLimits(3))},
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	if res := request(); res.Code != http.StatusOK || res.Header().Get("RateLimit-Policy") != `"free:token-1m";q=1;w=60` {
+		t.Fatalf("Expected the free tier to apply, got %d %q", res.Code, res.Header().Get("RateLimit-Policy"))
+	}
+	if res := request(); res.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected the free tier to be exhausted, got %d", res.Code)
+	}
+
+	claims["plan"] = "pro"
+	for i := 1; i <= 3; i++ {
+		if res := request(); res.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected the upgraded plan to apply immediately, got %d", i, res.Code)
+		}
+	}
+	if res := request(); res.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected the pro tier to be exhausted, got %d", res.Code)
+	}
+
+	claims["plan"] = "enterprise"
+	if res := request(); res.Code != http.StatusOK || res.Header().Get("RateLimit-Policy") != `"token";q=100;w=1` {
+		t.Fatalf("Unknown tiers should use the default limits, got %d %q", res.Code, res.Header().Get("RateLimit-Policy"))
+	}
+}
+
+func TestRateLimiterMiddleware_JWTNamespace(t *testing.T) {
+	keys := FirstKey(JWTClaimKey(JWTKeyConfig{Verifier: fakeVerifier{"token": {"sub": "victim"}}}), DefaultKeyExtractor(nil))
+	handler := RateLimiterMiddlewareWithConfig(limiter.NewMemoryRateLimiter(domain.LimiterConfig{TokenMaxRequests: 1, Window: time.Minute}), MiddlewareConfig{
+		Keys: keys,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(header, value string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(header, value)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	for _, apiKey := range []string{"victim", "jwt:victim", "raw:jwt:victim"} {
+		if code := request("API_KEY", apiKey); code != http.StatusOK {
+			t.Fatalf("API key %q: expected its own budget, got %d", apiKey, code)
+		}
+	}
+	if code := request("Authorization", "Bearer token"); code != http.StatusOK {
+		t.Fatalf("Unverified API keys should not spend the verified subject's budget, got %d", code)
+	}
+	if code := request("Authorization", "Bearer token"); code != http.StatusTooManyRequests {
+		t.Fatalf("Expected the verified subject's budget to be exhausted, got %d", code)
+	}
+	if code := request("API_KEY", "jwt:victim"); code != http.StatusTooManyRequests {
+		t.Fatalf("Expected the unverified key to keep its own exhausted budget, got %d", code)
+	}
+}
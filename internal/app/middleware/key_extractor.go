@@ -0,0 +1,228 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+)
+
+const (
+	compositeKeySeparator = ":"
+	jwtKeyPrefix          = "jwt:"
+	escapedKeyPrefix      = "raw:"
+)
+
+var ErrKeyNotFound = errors.New("rate limit key not found")
+
+type RequestKey struct {
+	Value    string
+	IsToken  bool
+	Tier     string
+	Verified bool
+	APIKey   *domain.APIKey
+}
+
+type KeyExtractor interface {
+	ExtractKey(r *http.Request) (RequestKey, error)
+}
+
+type KeyExtractorFunc func(r *http.Request) (RequestKey, error)
+
+func (f KeyExtractorFunc) ExtractKey(r *http.Request) (RequestKey, error) {
+	return f(r)
+}
+
+type KeyError struct {
+	Status  int
+	Message string
+}
+
+func (e *KeyError) Error() string {
+	return e.Message
+}
+
+func DefaultKeyExtractor(clientIP *ClientIPResolver) KeyExtractor {
+	return FirstKey(
+		HeaderKey("API_KEY", true),
+		QueryKey("/token", "token", true),
+		IPKey(clientIP, QueryKey("/ip", "ip", false)),
+		ClientIPKey(clientIP),
+	)
+}
+
+func HeaderKey(name string, isToken bool) KeyExtractor {
+	return KeyExtractorFunc(func(r *http.Request) (RequestKey, error) {
+		return presentKey(r.Header.Get(name), isToken)
+	})
+}
+
+func CookieKey(name string, isToken bool) KeyExtractor {
+	return KeyExtractorFunc(func(r *http.Request) (RequestKey, error) {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return RequestKey{}, ErrKeyNotFound
+		}
+		return presentKey(cookie.Value, isToken)
+	})
+}
+
+func QueryKey(path, param string, isToken bool) KeyExtractor {
+	return KeyExtractorFunc(func(r *http.Request) (RequestKey, error) {
+		if path != "" && r.URL.Path != path {
+			return RequestKey{}, ErrKeyNotFound
+		}
+		value := r.URL.Query().Get(param)
+		if value == "" && path != "" {
+			return RequestKey{}, &KeyError{
+				Status:  http.StatusBadRequest,
+				Message: fmt.Sprintf("Missing %s in query parameters", param),
+			}
+		}
+		return presentKey(value, isToken)
+	})
+}
+
+func PathParamKey(param string, isToken bool) KeyExtractor {
+	return KeyExtractorFunc(func(r *http.Request) (RequestKey, error) {
+		rctx := matchRoute(r)
+		if rctx == nil {
+			return RequestKey{}, ErrKeyNotFound
+		}
+		return presentKey(rctx.URLParam(param), isToken)
+	})
+}
+
+func RouteKey() KeyExtractor {
+	return KeyExtractorFunc(func(r *http.Request) (RequestKey, error) {
+		return RequestKey{Value: r.Method + " " + routePattern(r)}, nil
+	})
+}
+
+func ClientIPKey(clientIP *ClientIPResolver) KeyExtractor {
+	return KeyExtractorFunc(func(r *http.Request) (RequestKey, error) {
+		ip, err := clientIP.ClientIP(r)
+		if err != nil {
+			return RequestKey{}, err
+		}
+		return RequestKey{Value: clientIP.Key(ip)}, nil
+	})
+}
+
+func IPKey(clientIP *ClientIPResolver, extractor KeyExtractor) KeyExtractor {
+	return KeyExtractorFunc(func(r *http.Request) (RequestKey, error) {
+		key, err := extractor.ExtractKey(r)
+		if err != nil {
+			return RequestKey{}, err
+		}
+		key.Value = clientIP.Key(key.Value)
+		return key, nil
+	})
+}
+
+func FirstKey(extractors ...KeyExtractor) KeyExtractor {
+	return KeyExtractorFunc(func(r *http.Request) (RequestKey, error) {
+		for _, extractor := range extractors {
+			key, err := extractor.ExtractKey(r)
+			if errors.Is(err, ErrKeyNotFound) {
+				continue
+			}
+			return key, err
+		}
+		return RequestKey{}, ErrKeyNotFound
+	})
+}
+
+func CompositeKey(extractors ...KeyExtractor) KeyExtractor {
+	return KeyExtractorFunc(func(r *http.Request) (RequestKey, error) {
+		var composite RequestKey
+		values := make([]string, 0, len(extractors))
+		for _, extractor := range extractors {
+			key, err := extractor.ExtractKey(r)
+			if err != nil {
+				return RequestKey{}, err
+			}
+			values = append(values, key.Value)
+			composite.IsToken = composite.IsToken || key.IsToken
+			if composite.Tier == "" {
+				composite.Tier = key.Tier
+			}
+		}
+		if len(values) == 0 {
+			return RequestKey{}, ErrKeyNotFound
+		}
+		composite.Value = joinKeyParts(values)
+		return composite, nil
+	})
+}
+
+var keyPartEscaper = strings.NewReplacer(`\`, `\\`, compositeKeySeparator, `\`+compositeKeySeparator)
+
+// joinKeyParts joins values with the composite separator, escaping it (and the
+// escape character) inside each value so distinct tuples never share a key.
+func joinKeyParts(values []string) string {
+	escaped := make([]string, len(values))
+	for i, value := range values {
+		escaped[i] = keyPartEscaper.Replace(value)
+	}
+	return strings.Join(escaped, compositeKeySeparator)
+}
+
+func ParseKeyExtractor(spec string, clientIP *ClientIPResolver) (KeyExtractor, error) {
+	var alternatives []KeyExtractor
+	for _, alternative := range strings.Split(spec, ",") {
+		alternative = strings.TrimSpace(alternative)
+		if alternative == "" {
+			continue
+		}
+
+		var parts []KeyExtractor
+		for _, part := range strings.Split(alternative, "+") {
+			extractor, err := parseKeyPart(strings.TrimSpace(part), clientIP)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, extractor)
+		}
+		if len(parts) == 1 {
+			alternatives = append(alternatives, parts[0])
+		} else {
+			alternatives = append(alternatives, CompositeKey(parts...))
+		}
+	}
+	if len(alternatives) == 0 {
+		return nil, fmt.Errorf("invalid key extractor %q: no keys configured", spec)
+	}
+	return FirstKey(alternatives...), nil
+}
+
+func parseKeyPart(spec string, clientIP *ClientIPResolver) (KeyExtractor, error) {
+	source, isToken := strings.CutPrefix(spec, "token:")
+	kind, name, _ := strings.Cut(source, ":")
+	switch {
+	case kind == "default" && name == "":
+		return DefaultKeyExtractor(clientIP), nil
+	case kind == "ip" && name == "":
+		return ClientIPKey(clientIP), nil
+	case kind == "route" && name == "":
+		return RouteKey(), nil
+	case kind == "header" && name != "":
+		return HeaderKey(name, isToken), nil
+	case kind == "cookie" && name != "":
+		return CookieKey(name, isToken), nil
+	case kind == "query" && name != "":
+		return QueryKey("", name, isToken), nil
+	case kind == "param" && name != "":
+		return PathParamKey(name, isToken), nil
+	}
+	return nil, fmt.Errorf("invalid key %q: expected default, ip, route, header:<name>, cookie:<name>, query:<param> or param:<name>", spec)
+}
+
+func presentKey(value string, isToken bool) (RequestKey, error) {
+	if value == "" {
+		return RequestKey{}, ErrKeyNotFound
+	}
+	return RequestKey{Value: value, IsToken: isToken}, nil
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestDefaultKeyExtractor(t *testing.T) {
+	keys := DefaultKeyExtractor(nil)
+
+	tests := []struct {
+		name     string
+		target   string
+		apiKey   string
+		expected RequestKey
+		status   int
+	}{
+		{name: "API key header", target: "/ip?ip=10.0.0.1", apiKey: "abc", expected: RequestKey{Value: "abc", IsToken: true}},
+		{name: "Token query", target: "/token?token=xyz", expected: RequestKey{Value: "xyz", IsToken: true}},
+		{name: "IP query", target: "/ip?ip=::ffff:10.0.0.1", expected: RequestKey{Value: "10.0.0.1"}},
+		{name: "Remote address", target: "/", expected: RequestKey{Value: "192.0.2.1"}},
+		{name: "Missing token", target: "/token", status: http.StatusBadRequest},
+		{name: "Missing IP", target: "/ip", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.apiKey != "" {
+				req.Header.Set("API_KEY", tt.apiKey)
+			}
+			key, err := keys.ExtractKey(req)
+			var keyErr *KeyError
+			if tt.status != 0 {
+				if !errors.As(err, &keyErr) || keyErr.Status != tt.status {
+					t.Fatalf("Expected a %d key error, got %v", tt.status, err)
+				}
+				return
+			}
+			if err != nil || key != tt.expected {
+				t.Fatalf("Expected %+v, got %+v (%v)", tt.expected, key, err)
+			}
+		})
+	}
+}
+
+func TestParseKeyExtractor(t *testing.T) {
+	keys, err := ParseKeyExtractor("token:header:X-API-Key, header:X-Tenant-ID+route, cookie:session, ip", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var key RequestKey
+	router := chi.NewRouter()
+	router.Get("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		if key, err = keys.ExtractKey(r); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+	extract := func(req *http.Request) RequestKey {
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		return key
+	}
+
+	req := httptest.NewRequest("GET", "/orders/42", nil)
+	req.Header.Set("X-API-Key", "abc")
+	req.Header.Set("X-Tenant-ID", "acme")
+	if key := extract(req); key != (RequestKey{Value: "abc", IsToken: true}) {
+		t.Fatalf("Expected the first configured key to win, got %+v", key)
+	}
+
+	req.Header.Del("X-API-Key")
+	if key := extract(req); key != (RequestKey{Value: "acme:GET /orders/{id}"}) {
+		t.Fatalf("Expected a tenant and route composite key, got %+v", key)
+	}
+
+	req.Header.Set("X-Tenant-ID", `a:b\`)
+	if key := extract(req); key != (RequestKey{Value: `a\:b\\:GET /orders/{id}`}) {
+		t.Fatalf("Expected composite parts to be escaped, got %+v", key)
+	}
+
+	req.Header.Del("X-Tenant-ID")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+	if key := extract(req); key != (RequestKey{Value: "s1"}) {
+		t.Fatalf("Expected to fall back to the session cookie, got %+v", key)
+	}
+
+	req = httptest.NewRequest("GET", "/orders/42", nil)
+	if key := extract(req); key != (RequestKey{Value: "192.0.2.1"}) {
+		t.Fatalf("Expected to fall back to the client IP, got %+v", key)
+	}
+
+	for _, spec := range []string{"", "header:", "jwt:sub", "ip:x"} {
+		if _, err := ParseKeyExtractor(spec, nil); err == nil {
+			t.Fatalf("Expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestRateLimiterMiddleware_KeyExtractor(t *testing.T) {
+	rateLimiter := limiter.NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 1})
+	router := chi.NewRouter()
+	router.Use(RateLimiterMiddlewareWithConfig(rateLimiter, MiddlewareConfig{
+		Keys: PathParamKey("tenant", false),
+	}))
+	router.Get("/tenants/{tenant}/reports", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	request := func(target string) int {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest("GET", target, nil))
+		return res.Code
+	}
+
+	if code := request("/tenants/acme/reports"); code != http.StatusOK {
+		t.Fatalf("Expected the first tenant request to be allowed, got %d", code)
+	}
+	if code := request("/tenants/acme/reports"); code != http.StatusTooManyRequests {
+		t.Fatalf("Expected the tenant to be limited, got %d", code)
+	}
+	if code := request("/tenants/globex/reports"); code != http.StatusOK {
+		t.Fatalf("Expected tenants to be limited separately, got %d", code)
+	}
+	if code := request("/health"); code != http.StatusBadRequest {
+		t.Fatalf("Expected requests without a key to be rejected, got %d", code)
+	}
+}
+
+func TestExtractRequestKey(t *testing.T) {
+	keys := DefaultKeyExtractor(nil)
+
+	req := httptest.NewRequest("GET", "/quota", nil)
+	req.Header.Set("API_KEY", "jwt:acme:user-1")
+	key, err := ExtractRequestKey(keys, req)
+	if err != nil || key.Value != "raw:jwt:acme:user-1" {
+		t.Fatalf("Expected the limiter's namespaced key, got %+v (%v)", key, err)
+	}
+
+	if _, err := ExtractRequestKey(FirstKey(), httptest.NewRequest("GET", "/quota", nil)); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
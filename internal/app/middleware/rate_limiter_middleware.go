@@ -1,58 +1,181 @@
 package middleware
 
 import (
-	"net"
+	"errors"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
 	"github.com/ankardo/Rate-Limiter/internal/domain"
 	"go.uber.org/zap"
 )
 
-func RateLimiterMiddleware(limiter domain.Limiter) func(http.Handler) http.Handler {
+type MiddlewareConfig struct {
+	HeaderStyle string
+	Quota       domain.QuotaLimiter
+	Cost        CostFunc
+	Policies    []RoutePolicy
+	ClientIP    *ClientIPResolver
+	Keys        KeyExtractor
+	Tiers       []LimitTier
+	KeyLimiter  KeyLimiterFunc
+	KeyFallback KeyLimiterFunc
+	Failure     FailurePolicy
+	// Closers tracks the per-key limiters the middleware builds, so they are
+	// closed on shutdown along with the caller's own limiters.
+	Closers *limiter.Closers
+}
+
+func RateLimiterMiddleware(rateLimiter domain.Limiter) func(http.Handler) http.Handler {
+	return RateLimiterMiddlewareWithConfig(rateLimiter, MiddlewareConfig{HeaderStyle: HeaderStyleBoth})
+}
+
+func RateLimiterMiddlewareWithConfig(rateLimiter domain.Limiter, config MiddlewareConfig) func(http.Handler) http.Handler {
+	decisionLimiter := limiter.NewDecisionAdapter(rateLimiter)
+	keys := config.Keys
+	if keys == nil {
+		keys = DefaultKeyExtractor(config.ClientIP)
+	}
+	failure := newFailureHandler(config.Failure)
+	policyLimiters := make(map[string]domain.WeightedLimiter, len(config.Policies))
+	policyFallbacks := make(map[string]domain.WeightedLimiter, len(config.Policies))
+	policyFailures := make(map[string]failureHandler, len(config.Policies))
+	for _, policy := range config.Policies {
+		if policy.Limiter != nil {
+			policyLimiters[policy.Name] = limiter.NewDecisionAdapter(policy.Limiter)
+		}
+		if policy.Fallback != nil {
+			policyFallbacks[policy.Name] = limiter.NewDecisionAdapter(policy.Fallback)
+		}
+		if policy.FailureMode != "" {
+			policyFailures[policy.Name] = newFailureHandler(FailurePolicy{Mode: policy.FailureMode, Fallback: config.Failure.Fallback})
+		}
+	}
+
+	tierLimiters := make(map[string]domain.WeightedLimiter, len(config.Tiers))
+	tierFallbacks := make(map[string]domain.WeightedLimiter, len(config.Tiers))
+	for _, tier := range config.Tiers {
+		if tier.Limiter != nil {
+			tierLimiters[tier.Name] = limiter.NewDecisionAdapter(tier.Limiter)
+		}
+		if tier.Fallback != nil {
+			tierFallbacks[tier.Name] = limiter.NewDecisionAdapter(tier.Fallback)
+		}
+	}
+
+	if config.Closers == nil {
+		config.Closers = limiter.NewClosers()
+	}
+	keyLimiters := newKeyLimiters(config.KeyLimiter, config.Closers)
+	keyFallbacks := newKeyLimiters(config.KeyFallback, config.Closers)
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			var key string
-			isToken := false
-
-			if token := r.Header.Get("API_KEY"); token != "" {
-				key = token
-				isToken = true
-			} else if r.URL.Path == "/token" {
-				queryToken := r.URL.Query().Get("token")
-				if queryToken == "" {
-					http.Error(w, "Missing token in query parameters", http.StatusBadRequest)
-					return
-				}
-				key = queryToken
-				isToken = true
-			} else if r.URL.Path == "/ip" {
-				queryIP := r.URL.Query().Get("ip")
-				if queryIP == "" {
-					http.Error(w, "Missing IP in query parameters", http.StatusBadRequest)
-					return
+			policy, matched := matchRoutePolicy(config.Policies, r)
+			if matched && policy.Exempt {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			requestKey, lookupErr, ok := extractKey(w, r, keys)
+			if !ok {
+				return
+			}
+			key, isToken := requestKey.Value, requestKey.IsToken
+
+			// A route policy replaces only the global limits: per-key and plan
+			// limits belong to the client and still apply on every route.
+			var scopes []scopedLimiter
+			if policyLimiter, routed := policyLimiters[policy.Name]; routed && matched {
+				scopes = append(scopes, scopedLimiter{policyLimiter, policyFallbacks[policy.Name], key + "@" + policy.Name, policy.Name})
+			}
+			if keyLimiter, overridden := keyLimiters.limiterFor(requestKey.APIKey); overridden {
+				keyFallback, _ := keyFallbacks.limiterFor(requestKey.APIKey)
+				scopes = append(scopes, scopedLimiter{keyLimiter, keyFallback, key + "@" + apiKeyScope, apiKeyScope})
+			} else if tierLimiter, tiered := tierLimiters[requestKey.Tier]; tiered {
+				scopes = append(scopes, scopedLimiter{tierLimiter, tierFallbacks[requestKey.Tier], key + "@" + requestKey.Tier, requestKey.Tier})
+			}
+			if len(scopes) == 0 {
+				scopes = append(scopes, scopedLimiter{decisionLimiter, nil, key, ""})
+			}
+
+			cost := int64(1)
+			if config.Cost != nil {
+				cost = max(1, config.Cost(r))
+			}
+
+			logger.Debug("Processing request",
+				zap.String("key", key),
+				zap.Bool("isToken", isToken),
+				zap.Int64("cost", cost),
+				zap.String("route", policy.Name),
+				zap.String("tier", requestKey.Tier),
+			)
+
+			routeFailure := failure
+			if policyFailure, ok := policyFailures[policy.Name]; ok && matched {
+				routeFailure = policyFailure
+			}
+
+			var decision domain.Decision
+			for i, scoped := range scopes {
+				var current domain.Decision
+				err := lookupErr
+				if err == nil {
+					current, err = scoped.limiter.EvaluateCost(r.Context(), scoped.key, isToken, cost)
 				}
-				key = queryIP
-			} else {
-				ip, _, err := net.SplitHostPort(r.RemoteAddr)
 				if err != nil {
-					logger.Error("Failed to parse IP address", err, zap.String("RemoteAddr", r.RemoteAddr))
-					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-					return
+					if requestCanceled(r, scoped.key, err) {
+						return
+					}
+					var mode string
+					current, mode = routeFailure.withFallback(scoped.fallback).degrade(r.Context(), scoped.scope, scoped.key, isToken, cost, err)
+					if mode == domain.FailureModeClosed {
+						serviceUnavailable(w)
+						return
+					}
+				}
+				if scoped.scope != "" {
+					current = routeDecision(current, scoped.scope)
+				}
+				if i == 0 {
+					decision = current
+				} else {
+					decision = mergeDecisions(decision, current)
+				}
+				if !decision.Allowed {
+					break
 				}
-				key = ip
 			}
 
-			logger.Debug("Processing request", zap.String("key", key), zap.Bool("isToken", isToken))
-
-			allowed, err := limiter.AllowRequest(key, isToken)
-			if err != nil {
-				logger.Error("Rate limiter error", err, zap.String("key", key))
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-				return
+			if decision.Allowed && isToken && config.Quota != nil && lookupErr == nil {
+				quotaDecision, err := config.Quota.EvaluateQuota(r.Context(), key, cost)
+				if err != nil {
+					if requestCanceled(r, key, err) {
+						return
+					}
+					if !routeFailure.bypass("quota", key, err) {
+						serviceUnavailable(w)
+						return
+					}
+					quotaDecision = domain.Decision{Allowed: true, Reason: domain.ReasonDegraded}
+				}
+				if !quotaDecision.Allowed {
+					decision = quotaDecision
+				}
 			}
 
-			if !allowed {
+			writeRateLimitHeaders(w, decision, config.HeaderStyle, time.Now())
+
+			if !decision.Allowed {
+				logger.Debug("Request denied",
+					zap.String("key", key),
+					zap.String("policy", decision.Policy),
+					zap.String("reason", decision.Reason),
+					zap.Duration("retryAfter", decision.RetryAfter),
+				)
 				http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
 				return
 			}
@@ -61,3 +184,93 @@ func RateLimiterMiddleware(limiter domain.Limiter) func(http.Handler) http.Handl
 		})
 	}
 }
+
+// extractKey writes the error response when no key can be resolved. A failed
+// registry lookup still yields the unverified key, and its cause is returned so
+// the caller can apply its failure mode.
+func extractKey(w http.ResponseWriter, r *http.Request, keys KeyExtractor) (key RequestKey, lookupErr error, ok bool) {
+	key, err := keys.ExtractKey(r)
+	var lookup *LookupError
+	if errors.As(err, &lookup) {
+		return namespaced(lookup.Key), lookup.Err, true
+	}
+	if err == nil {
+		return namespaced(key), nil, true
+	}
+
+	var keyErr *KeyError
+	switch {
+	case errors.As(err, &keyErr):
+		http.Error(w, keyErr.Message, keyErr.Status)
+	case errors.Is(err, ErrKeyNotFound):
+		http.Error(w, "Missing rate limit key", http.StatusBadRequest)
+	default:
+		logger.Error("Failed to extract rate limit key", err, zap.String("RemoteAddr", r.RemoteAddr))
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+	return RequestKey{}, nil, false
+}
+
+// ExtractRequestKey resolves the key the limiter charges for r, so handlers
+// that report on a client's usage read the same counters.
+func ExtractRequestKey(keys KeyExtractor, r *http.Request) (RequestKey, error) {
+	key, err := keys.ExtractKey(r)
+	if err != nil {
+		return RequestKey{}, err
+	}
+	return namespaced(key), nil
+}
+
+// namespaced keeps unverified tokens out of the jwt: namespace, so sending a
+// verified subject as API_KEY never spends that subject's limits or quota.
+func namespaced(key RequestKey) RequestKey {
+	if key.IsToken && !key.Verified && (strings.HasPrefix(key.Value, jwtKeyPrefix) || strings.HasPrefix(key.Value, escapedKeyPrefix)) {
+		key.Value = escapedKeyPrefix + key.Value
+	}
+	return key
+}
+
+type scopedLimiter struct {
+	limiter  domain.WeightedLimiter
+	fallback domain.WeightedLimiter
+	key      string
+	scope    string
+}
+
+// mergeDecisions combines an allowed decision with the next scope's decision.
+// A denial wins; otherwise the scope with the fewest remaining requests drives
+// the headers, and every limit is listed in the policy header.
+func mergeDecisions(allowed, next domain.Decision) domain.Decision {
+	limits := append([]domain.LimitStatus{}, decisionLimits(allowed)...)
+	limits = append(limits, decisionLimits(next)...)
+	merged := allowed
+	if !next.Allowed || allowed.Limit <= 0 || (next.Limit > 0 && next.Remaining < allowed.Remaining) {
+		merged = next
+	}
+	merged.Limits = limits
+	return merged
+}
+
+func decisionLimits(decision domain.Decision) []domain.LimitStatus {
+	if len(decision.Limits) > 0 || decision.Limit <= 0 {
+		return decision.Limits
+	}
+	return []domain.LimitStatus{{
+		Policy:    decision.Policy,
+		Limit:     decision.Limit,
+		Remaining: decision.Remaining,
+		Window:    decision.Window,
+		ResetAt:   decision.ResetAt,
+	}}
+}
+
+func routeDecision(decision domain.Decision, route string) domain.Decision {
+	decision.Policy = route + ":" + decision.Policy
+	limits := make([]domain.LimitStatus, len(decision.Limits))
+	for i, limit := range decision.Limits {
+		limit.Policy = route + ":" + limit.Policy
+		limits[i] = limit
+	}
+	decision.Limits = limits
+	return decision
+}
@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
 	"github.com/ankardo/Rate-Limiter/internal/domain"
@@ -38,3 +40,167 @@ func TestRateLimiterMiddleware(t *testing.T) {
 		}
 	}
 }
+
+func TestRateLimiterMiddleware_Headers(t *testing.T) {
+	tests := []struct {
+		name          string
+		style         string
+		expectHeaders []string
+		absentHeaders []string
+	}{
+		{
+			name:          "Legacy headers",
+			style:         HeaderStyleLegacy,
+			expectHeaders: []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
+			absentHeaders: []string{"RateLimit", "RateLimit-Policy"},
+		},
+		{
+			name:          "Draft headers",
+			style:         HeaderStyleDraft,
+			expectHeaders: []string{"RateLimit", "RateLimit-Policy"},
+			absentHeaders: []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
+		},
+		{
+			name:          "Both header styles",
+			style:         HeaderStyleBoth,
+			expectHeaders: []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "RateLimit", "RateLimit-Policy"},
+		},
+		{
+			name:          "No rate limit headers",
+			style:         HeaderStyleNone,
+			absentHeaders: []string{"X-RateLimit-Limit", "RateLimit", "RateLimit-Policy"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rateLimiter := limiter.NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 2})
+			handler := RateLimiterMiddlewareWithConfig(rateLimiter, MiddlewareConfig{HeaderStyle: tt.style})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			for i := 1; i <= 3; i++ {
+				req := httptest.NewRequest("GET", "/", nil)
+				res := httptest.NewRecorder()
+				handler.ServeHTTP(res, req)
+
+				for _, header := range tt.expectHeaders {
+					if res.Header().Get(header) == "" {
+						t.Fatalf("Request %d: expected header %s to be set", i, header)
+					}
+				}
+				for _, header := range tt.absentHeaders {
+					if res.Header().Get(header) != "" {
+						t.Fatalf("Request %d: expected header %s to be absent, got %q", i, header, res.Header().Get(header))
+					}
+				}
+
+				if i <= 2 && res.Header().Get("Retry-After") != "" {
+					t.Fatalf("Request %d: allowed responses should not carry Retry-After", i)
+				}
+				if i > 2 && (res.Code != http.StatusTooManyRequests || res.Header().Get("Retry-After") != "1") {
+					t.Fatalf("Request %d: expected 429 with Retry-After 1, got %d/%q", i, res.Code, res.Header().Get("Retry-After"))
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterMiddleware_HeaderValues(t *testing.T) {
+	rateLimiter := limiter.NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 5})
+	handler := RateLimiterMiddleware(rateLimiter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	if got := res.Header().Get("X-RateLimit-Limit"); got != "5" {
+		t.Fatalf("Expected X-RateLimit-Limit 5, got %q", got)
+	}
+	if got := res.Header().Get("X-RateLimit-Remaining"); got != "4" {
+		t.Fatalf("Expected X-RateLimit-Remaining 4, got %q", got)
+	}
+	if got := res.Header().Get("RateLimit-Policy"); got != `"ip";q=5;w=1` {
+		t.Fatalf("Unexpected RateLimit-Policy %q", got)
+	}
+	if got := res.Header().Get("RateLimit"); got != `"ip";r=4;t=1` {
+		t.Fatalf("Unexpected RateLimit %q", got)
+	}
+}
+
+func TestRateLimiterMiddleware_StackedHeaders(t *testing.T) {
+	rateLimiter := limiter.NewMemoryStackedLimiter(domain.LimiterConfig{
+		Limits: []domain.LimitRule{
+			{MaxRequests: 10, Window: time.Second},
+			{MaxRequests: 3, Window: time.Minute},
+		},
+	})
+
+	handler := RateLimiterMiddleware(rateLimiter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/ip?ip=10.0.0.1", nil)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	if got := res.Header().Get("RateLimit-Policy"); got != `"ip-1s";q=10;w=1, "ip-1m";q=3;w=60` {
+		t.Fatalf("Unexpected RateLimit-Policy %q", got)
+	}
+	if got := res.Header().Get("RateLimit"); got != `"ip-1m";r=2;t=60` {
+		t.Fatalf("Expected the most restrictive limit to be reported, got %q", got)
+	}
+	if got := res.Header().Get("X-RateLimit-Limit"); got != "3" {
+		t.Fatalf("Expected X-RateLimit-Limit 3, got %q", got)
+	}
+}
+
+type exhaustedQuota struct{}
+
+func (exhaustedQuota) EvaluateQuota(ctx context.Context, token string, cost int64) (domain.Decision, error) {
+	return domain.Decision{
+		Limit:      1000,
+		Window:     24 * time.Hour,
+		RetryAfter: time.Hour,
+		ResetAt:    time.Now().Add(time.Hour),
+		Policy:     "token-daily",
+		Reason:     domain.ReasonQuotaExceeded,
+	}, nil
+}
+
+func (exhaustedQuota) Usage(ctx context.Context, token string) ([]domain.QuotaUsage, error) {
+	return nil, nil
+}
+
+func TestRateLimiterMiddleware_Quota(t *testing.T) {
+	rateLimiter := limiter.NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 5, TokenMaxRequests: 5})
+	handler := RateLimiterMiddlewareWithConfig(rateLimiter, MiddlewareConfig{
+		HeaderStyle: HeaderStyleBoth,
+		Quota:       exhaustedQuota{},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("API_KEY", "abc")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected an exhausted quota to deny the request, got %d", res.Code)
+	}
+	if got := res.Header().Get("Retry-After"); got != "3600" {
+		t.Fatalf("Expected Retry-After until the quota resets, got %q", got)
+	}
+	if got := res.Header().Get("RateLimit-Policy"); got != `"token-daily";q=1000;w=86400` {
+		t.Fatalf("Unexpected RateLimit-Policy %q", got)
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("Quotas should only apply to tokens, got %d", res.Code)
+	}
+}
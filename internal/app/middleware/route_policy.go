@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+type RoutePolicy struct {
+	domain.RoutePolicy
+	Limiter  domain.Limiter
+	Fallback domain.Limiter
+}
+
+func (p RoutePolicy) matches(method, pattern string) bool {
+	if p.Method != "" && p.Method != "*" && !strings.EqualFold(p.Method, method) {
+		return false
+	}
+	if p.Pattern == pattern {
+		return true
+	}
+	prefix, wildcard := strings.CutSuffix(p.Pattern, "*")
+	return wildcard && strings.HasPrefix(pattern, prefix)
+}
+
+func matchRoutePolicy(policies []RoutePolicy, r *http.Request) (RoutePolicy, bool) {
+	if len(policies) == 0 {
+		return RoutePolicy{}, false
+	}
+
+	pattern := routePattern(r)
+	for _, policy := range policies {
+		if policy.matches(r.Method, pattern) {
+			return policy, true
+		}
+	}
+	return RoutePolicy{}, false
+}
+
+func routePattern(r *http.Request) string {
+	if tctx := matchRoute(r); tctx != nil {
+		return tctx.RoutePattern()
+	}
+	return r.URL.Path
+}
+
+func matchRoute(r *http.Request) *chi.Context {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return nil
+	}
+
+	tctx := chi.NewRouteContext()
+	if !rctx.Routes.Match(tctx, r.Method, r.URL.Path) {
+		return nil
+	}
+	return tctx
+}
+
+func ExemptRoutes(policies []RoutePolicy, middleware func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		limited := middleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if policy, ok := matchRoutePolicy(policies, r); ok && policy.Exempt {
+				next.ServeHTTP(w, r)
+				return
+			}
+			limited.ServeHTTP(w, r)
+		})
+	}
+}
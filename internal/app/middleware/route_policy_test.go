@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRateLimiterMiddleware_RoutePolicies(t *testing.T) {
+	routeLimiter := func(limit int64) domain.Limiter {
+		rules := []domain.LimitRule{{MaxRequests: limit, Window: time.Minute}}
+		return limiter.NewMemoryStackedLimiter(domain.LimiterConfig{Limits: rules, TokenLimits: rules})
+	}
+	policies := []RoutePolicy{
+		{RoutePolicy: domain.RoutePolicy{Name: "post/orders/*", Method: "POST", Pattern: "/orders/*"}, Limiter: routeLimiter(1)},
+		{RoutePolicy: domain.RoutePolicy{Name: "/health", Pattern: "/health", Exempt: true}},
+		{RoutePolicy: domain.RoutePolicy{Name: "get/*", Method: "GET", Pattern: "/*"}, Limiter: routeLimiter(3)},
+	}
+	rateLimiter := limiter.NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 2, Window: time.Minute})
+
+	router := chi.NewRouter()
+	router.Group(func(r chi.Router) {
+		r.Use(RateLimiterMiddlewareWithConfig(rateLimiter, MiddlewareConfig{
+			HeaderStyle: HeaderStyleDraft,
+			Policies:    policies,
+		}))
+		ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+		r.Get("/", ok)
+		r.Get("/health", ok)
+		r.Post("/orders/{id}", ok)
+		r.Delete("/orders/{id}", ok)
+	})
+
+	steps := []struct {
+		method string
+		path   string
+		status int
+		policy string
+	}{
+		{method: "POST", path: "/orders/1", status: http.StatusOK, policy: "post/orders/*:ip-1m"},
+		{method: "POST", path: "/orders/2", status: http.StatusTooManyRequests, policy: "post/orders/*:ip-1m"},
+		{method: "GET", path: "/", status: http.StatusOK, policy: "get/*:ip-1m"},
+		{method: "GET", path: "/", status: http.StatusOK, policy: "get/*:ip-1m"},
+		{method: "GET", path: "/", status: http.StatusOK, policy: "get/*:ip-1m"},
+		{method: "GET", path: "/", status: http.StatusTooManyRequests, policy: "get/*:ip-1m"},
+		{method: "DELETE", path: "/orders/1", status: http.StatusOK, policy: "ip"},
+		{method: "DELETE", path: "/orders/1", status: http.StatusOK, policy: "ip"},
+		{method: "DELETE", path: "/orders/1", status: http.StatusTooManyRequests, policy: "ip"},
+	}
+	for i, step := range steps {
+		req := httptest.NewRequest(step.method, step.path, nil)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if res.Code != step.status {
+			t.Fatalf("Request %d (%s %s): expected %d, got %d", i+1, step.method, step.path, step.status, res.Code)
+		}
+		if got := res.Header().Get("RateLimit-Policy"); !strings.HasPrefix(got, `"`+step.policy+`"`) {
+			t.Fatalf("Request %d (%s %s): expected policy %q, got %q", i+1, step.method, step.path, step.policy, got)
+		}
+	}
+
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest("GET", "/health", nil)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if res.Code != http.StatusOK || res.Header().Get("RateLimit-Policy") != "" {
+			t.Fatalf("Exempt routes should never be limited, got %d %v", res.Code, res.Header())
+		}
+	}
+}
+
+func TestRateLimiterMiddleware_RoutePolicyPrecedence(t *testing.T) {
+	stacked := func(limit int64) domain.Limiter {
+		rules := []domain.LimitRule{{MaxRequests: limit, Window: time.Minute}}
+		return limiter.NewMemoryStackedLimiter(domain.LimiterConfig{Limits: rules, TokenLimits: rules})
+	}
+	registry := fakeRegistry{
+		"custom": {Owner: "acme", Plan: "pro", Limits: []domain.LimitRule{{MaxRequests: 1, Window: time.Minute}}},
+		"plan":   {Owner: "globex", Plan: "pro"},
+	}
+	handler := RateLimiterMiddlewareWithConfig(limiter.NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 1, Window: time.Minute}), MiddlewareConfig{
+		HeaderStyle: HeaderStyleDraft,
+		Keys:        RegisteredKey(registry, UnknownAPIKeyIP, ClientIPKey(nil), DefaultKeyExtractor(nil)),
+		Policies:    []RoutePolicy{{RoutePolicy: domain.RoutePolicy{Name: "get/*", Method: "GET", Pattern: "/*"}, Limiter: stacked(3)}},
+		Tiers:       []LimitTier{{LimitTier: domain.LimitTier{Name: "pro"}, Limiter: stacked(2)}},
+		KeyLimiter: func(limits []domain.LimitRule) domain.Limiter {
+			return limiter.NewMemoryStackedLimiter(domain.LimiterConfig{Limits: limits, TokenLimits: limits})
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	steps := []struct {
+		token  string
+		status int
+		policy string
+	}{
+		{token: "custom", status: http.StatusOK, policy: `"get/*:token-1m";q=3;w=60, "key:token-1m";q=1;w=60`},
+		{token: "custom", status: http.StatusTooManyRequests},
+		{token: "plan", status: http.StatusOK, policy: `"get/*:token-1m";q=3;w=60, "pro:token-1m";q=2;w=60`},
+		{token: "plan", status: http.StatusOK},
+		{token: "plan", status: http.StatusTooManyRequests},
+		{status: http.StatusOK, policy: `"get/*:ip-1m";q=3;w=60`},
+		{status: http.StatusOK},
+		{status: http.StatusOK},
+		{status: http.StatusTooManyRequests},
+	}
+	for i, step := range steps {
+		req := httptest.NewRequest("GET", "/", nil)
+		if step.token != "" {
+			req.Header.Set("API_KEY", step.token)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		if res.Code != step.status {
+			t.Fatalf("Request %d (%q): expected %d, got %d", i+1, step.token, step.status, res.Code)
+		}
+		if step.policy != "" && res.Header().Get("RateLimit-Policy") != step.policy {
+			t.Fatalf("Request %d (%q): expected policy %q, got %q", i+1, step.token, step.policy, res.Header().Get("RateLimit-Policy"))
+		}
+	}
+}
+
+func TestExemptRoutes(t *testing.T) {
+	policies := []RoutePolicy{{RoutePolicy: domain.RoutePolicy{Name: "/health", Pattern: "/health", Exempt: true}}}
+	deny := func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+	}
+	handler := ExemptRoutes(policies, deny)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for path, status := range map[string]int{"/health": http.StatusOK, "/": http.StatusTooManyRequests} {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+		if res.Code != status {
+			t.Fatalf("%s: expected %d, got %d", path, status, res.Code)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package middleware
+
+import "github.com/ankardo/Rate-Limiter/internal/domain"
+
+type LimitTier struct {
+	domain.LimitTier
+	Limiter  domain.Limiter
+	Fallback domain.Limiter
+}
@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"context"
+	"net/netip"
+)
+
+const (
+	AccessAllow = "allow"
+	AccessDeny  = "deny"
+)
+
+type AccessListStore interface {
+	AccessNetworks(ctx context.Context) (allow, deny []string, err error)
+}
+
+type AccessChecker interface {
+	Access(addr netip.Addr) string
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "context"
+
+type APIKey struct {
+	Hash   string
+	Owner  string
+	Plan   string
+	Limits []LimitRule
+}
+
+type APIKeyRecord struct {
+	Owner  string
+	Plan   string
+	Limits string
+}
+
+type APIKeyStore interface {
+	APIKey(ctx context.Context, hash string) (APIKeyRecord, bool, error)
+}
+
+type APIKeyRegistry interface {
+	Lookup(ctx context.Context, token string) (APIKey, bool, error)
+}
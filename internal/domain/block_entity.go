@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const BlockReasonManual = "manual"
+
+type Block struct {
+	Key       string
+	Reason    string
+	ExpiresIn time.Duration
+}
+
+type BlockStore interface {
+	SetBlock(ctx context.Context, key, reason string, duration int64) error
+	BlockedFor(ctx context.Context, key string) (time.Duration, error)
+	ListBlocks(ctx context.Context) ([]Block, error)
+	DeleteBlock(ctx context.Context, key string) error
+}
+
+type BlockManager interface {
+	ListBlocks(ctx context.Context) ([]Block, error)
+	LiftBlock(ctx context.Context, key string) error
+}
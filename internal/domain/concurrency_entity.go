@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const ReasonConcurrencyExceeded = "concurrency_exceeded"
+
+type Lease struct {
+	Key      string
+	ID       string
+	Duration time.Duration
+}
+
+type ConcurrencyResult struct {
+	Acquired bool
+	InFlight int64
+}
+
+type ConcurrencyStore interface {
+	AcquireSlot(ctx context.Context, key, id string, limit, lease int64) (ConcurrencyResult, error)
+	RenewSlot(ctx context.Context, key, id string, lease int64) (bool, error)
+	ReleaseSlot(ctx context.Context, key, id string) error
+}
+
+type ConcurrencyLimiter interface {
+	Acquire(ctx context.Context, key string, isToken bool) (Decision, Lease, error)
+	Renew(ctx context.Context, lease Lease) error
+	Release(ctx context.Context, lease Lease) error
+}
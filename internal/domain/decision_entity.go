@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	ReasonAllowed       = "allowed"
+	ReasonLimitExceeded = "limit_exceeded"
+	ReasonBlocked       = "blocked"
+	ReasonDegraded      = "degraded"
+)
+
+const (
+	PolicyIP    = "ip"
+	PolicyToken = "token"
+)
+
+type LimitStatus struct {
+	Policy    string
+	Limit     int64
+	Remaining int64
+	Window    time.Duration
+	ResetAt   time.Time
+}
+
+type Decision struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	Window     time.Duration
+	ResetAt    time.Time
+	RetryAfter time.Duration
+	Policy     string
+	Reason     string
+	Limits     []LimitStatus
+}
+
+type DecisionLimiter interface {
+	Limiter
+	Evaluate(ctx context.Context, key string, isToken bool) (Decision, error)
+}
+
+type WeightedLimiter interface {
+	DecisionLimiter
+	EvaluateCost(ctx context.Context, key string, isToken bool, cost int64) (Decision, error)
+}
+
+type StoreResult struct {
+	Allowed    bool
+	Blocked    bool
+	Remaining  int64
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+}
+
+type LimitResult struct {
+	Remaining  int64
+	ResetAfter time.Duration
+}
+
+type StackedResult struct {
+	Allowed    bool
+	Blocked    bool
+	Tripped    int
+	RetryAfter time.Duration
+	Limits     []LimitResult
+}
@@ -0,0 +1,11 @@
+package domain
+
+const (
+	FailureModeOpen   = "open"
+	FailureModeClosed = "closed"
+	FailureModeMemory = "memory"
+)
+
+func ValidFailureMode(mode string) bool {
+	return mode == FailureModeOpen || mode == FailureModeClosed || mode == FailureModeMemory
+}
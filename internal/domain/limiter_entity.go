@@ -1,19 +1,77 @@
 package domain
 
+import (
+	"context"
+	"time"
+)
+
+const (
+	StrategyWindow        = "window"
+	StrategyTokenBucket   = "token_bucket"
+	StrategyGCRA          = "gcra"
+	StrategySlidingLog    = "sliding_log"
+	StrategySlidingWindow = "sliding_window"
+	StrategyStacked       = "stacked"
+)
+
+type LimitRule struct {
+	MaxRequests int64
+	Window      time.Duration
+}
+
 type LimiterConfig struct {
-	TokenMaxRequests int
-	MaxRequests      int
-	BlockDuration    int64
-	TTLExpiration    int64
+	TokenMaxRequests   int
+	MaxRequests        int
+	BlockDuration      int64
+	BurstCapacity      int
+	TokenBurstCapacity int
+	Window             time.Duration
+	TokenWindow        time.Duration
+	CleanupInterval    int64
+	MaxKeys            int
+	Limits             []LimitRule
+	TokenLimits        []LimitRule
+	MaxConcurrent      int
+	TokenMaxConcurrent int
+	LeaseDuration      time.Duration
+	// Clock replaces time.Now in the memory limiters, letting tests step
+	// through windows and blocks without sleeping.
+	Clock func() time.Time
 }
 
 type Limiter interface {
-	AllowRequest(key string, isToken bool) (bool, error)
-	BlockKey(key string, duration int64) error
+	AllowRequest(ctx context.Context, key string, isToken bool) (bool, error)
+	BlockKey(ctx context.Context, key string, duration int64) error
 }
 
 type RateLimiterStore interface {
-	Increment(key string) (int64, error)
-	GetTTL(key string) (int64, error)
-	SetExpiration(key string, duration int64) error
+	Increment(ctx context.Context, key string, cost int64) (int64, error)
+	GetTTL(ctx context.Context, key string) (int64, error)
+	SetExpiration(ctx context.Context, key string, duration int64) error
+}
+
+type AtomicRateLimiterStore interface {
+	RateLimiterStore
+	IncrementAndCheck(ctx context.Context, key string, limit, window, blockDuration, cost int64) (StoreResult, error)
+}
+
+type TokenBucketStore interface {
+	BlockStore
+	TakeToken(ctx context.Context, key string, capacity int64, refillPerSecond float64, blockDuration, cost int64) (StoreResult, error)
+}
+
+type GCRAStore interface {
+	BlockStore
+	UpdateTAT(ctx context.Context, key string, emissionInterval, burst, blockDuration, cost int64) (StoreResult, error)
+}
+
+type SlidingWindowStore interface {
+	BlockStore
+	AddToLog(ctx context.Context, key, member string, limit, window, blockDuration, cost int64) (StoreResult, error)
+	IncrementWindowCounter(ctx context.Context, key string, limit, window, blockDuration, cost int64) (StoreResult, error)
+}
+
+type StackedLimitStore interface {
+	BlockStore
+	IncrementLimits(ctx context.Context, key string, rules []LimitRule, blockDuration, cost int64) (StackedResult, error)
 }
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	QuotaDaily   = "daily"
+	QuotaMonthly = "monthly"
+)
+
+const ReasonQuotaExceeded = "quota_exceeded"
+
+type QuotaRule struct {
+	Period string
+	Limit  int64
+}
+
+type QuotaWindow struct {
+	Period  string
+	Limit   int64
+	Start   time.Time
+	ResetAt time.Time
+}
+
+type QuotaUsage struct {
+	Period    string
+	Limit     int64
+	Used      int64
+	Remaining int64
+	ResetAt   time.Time
+}
+
+type QuotaResult struct {
+	Allowed bool
+	Used    []int64
+}
+
+type QuotaStore interface {
+	ConsumeQuota(ctx context.Context, key string, windows []QuotaWindow, cost int64) (QuotaResult, error)
+	QuotaUsed(ctx context.Context, key string, windows []QuotaWindow) ([]int64, error)
+}
+
+type QuotaLimiter interface {
+	EvaluateQuota(ctx context.Context, token string, cost int64) (Decision, error)
+	Usage(ctx context.Context, token string) ([]QuotaUsage, error)
+}
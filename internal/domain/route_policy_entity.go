@@ -0,0 +1,10 @@
+package domain
+
+type RoutePolicy struct {
+	Name        string
+	Method      string
+	Pattern     string
+	Limits      []LimitRule
+	Exempt      bool
+	FailureMode string
+}
@@ -0,0 +1,6 @@
+package domain
+
+type LimitTier struct {
+	Name   string
+	Limits []LimitRule
+}
@@ -3,3 +3,22 @@ package dto
 type ErrorResponse struct {
 	Message string `json:"message"`
 }
+
+type BlockResponse struct {
+	Key              string `json:"key"`
+	Reason           string `json:"reason"`
+	ExpiresInSeconds int64  `json:"expires_in_seconds"`
+}
+
+type QuotaUsageResponse struct {
+	Period    string `json:"period"`
+	Limit     int64  `json:"limit"`
+	Used      int64  `json:"used"`
+	Remaining int64  `json:"remaining"`
+	ResetAt   string `json:"reset_at"`
+}
+
+type QuotaResponse struct {
+	Token  string               `json:"token"`
+	Quotas []QuotaUsageResponse `json:"quotas"`
+}
@@ -0,0 +1,111 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+var ErrCircuitOpen = errors.New("redis circuit breaker is open")
+
+const poolTimeoutMessage = "redis: connection pool timeout"
+
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     string
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	now       func() time.Time
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: max(1, threshold),
+		cooldown:  cooldown,
+		state:     CircuitClosed,
+		now:       time.Now,
+	}
+}
+
+func (c *CircuitBreaker) Allow() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case CircuitOpen:
+		if c.now().Sub(c.openedAt) < c.cooldown {
+			return ErrCircuitOpen
+		}
+		c.state, c.probing = CircuitHalfOpen, true
+		logger.Info("Redis circuit breaker half-open, probing")
+	case CircuitHalfOpen:
+		if c.probing {
+			return ErrCircuitOpen
+		}
+		c.probing = true
+	}
+	return nil
+}
+
+func (c *CircuitBreaker) Record(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if errors.Is(err, context.Canceled) {
+		c.probing = false
+		return
+	}
+	if !unavailable(err) {
+		if c.state != CircuitClosed {
+			logger.Info("Redis circuit breaker closed")
+		}
+		c.state, c.failures, c.probing = CircuitClosed, 0, false
+		return
+	}
+
+	c.failures++
+	if c.state == CircuitHalfOpen || c.failures >= c.threshold {
+		if c.state != CircuitOpen {
+			logger.Error("Redis circuit breaker opened", err, zap.Int("failures", c.failures), zap.Duration("cooldown", c.cooldown))
+		}
+		c.state, c.openedAt, c.probing = CircuitOpen, c.now(), false
+	}
+}
+
+func (c *CircuitBreaker) State() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// unavailable reports whether err means Redis could not be reached. Replies such
+// as script, WRONGTYPE or argument errors prove the server is up.
+func unavailable(err error) bool {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, redis.ErrClosed):
+		return true
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.As(err, &netErr):
+		return true
+	default:
+		return err.Error() == poolTimeoutMessage
+	}
+}
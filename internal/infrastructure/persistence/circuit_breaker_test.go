@@ -0,0 +1,104 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	breaker := NewCircuitBreaker(3, 5*time.Second)
+	breaker.now = func() time.Time { return now }
+	failure := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+	for i := 1; i <= 2; i++ {
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("Call %d: a closed breaker should allow calls, got %v", i, err)
+		}
+		breaker.Record(failure)
+	}
+	breaker.Record(nil)
+	breaker.Record(redis.Nil)
+	breaker.Record(context.Canceled)
+	if state := breaker.State(); state != CircuitClosed {
+		t.Fatalf("A success should reset the failure count, got %s", state)
+	}
+
+	for i := 1; i <= 3; i++ {
+		breaker.Record(failure)
+	}
+	if state := breaker.State(); state != CircuitOpen {
+		t.Fatalf("Expected the breaker to open after 3 consecutive failures, got %s", state)
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("An open breaker should reject calls, got %v", err)
+	}
+
+	now = now.Add(5 * time.Second)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("The breaker should let a probe through after the cooldown, got %v", err)
+	}
+	if state := breaker.State(); state != CircuitHalfOpen {
+		t.Fatalf("Expected a half-open breaker, got %s", state)
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Only one probe should run at a time, got %v", err)
+	}
+
+	breaker.Record(failure)
+	if state := breaker.State(); state != CircuitOpen {
+		t.Fatalf("A failed probe should reopen the breaker, got %s", state)
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("A reopened breaker should wait for a new cooldown, got %v", err)
+	}
+
+	now = now.Add(5 * time.Second)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	breaker.Record(nil)
+	if state := breaker.State(); state != CircuitClosed {
+		t.Fatalf("A successful probe should close the breaker, got %s", state)
+	}
+}
+
+func TestCircuitBreaker_OnlyCountsUnavailability(t *testing.T) {
+	breaker := NewCircuitBreaker(2, time.Minute)
+	replies := []error{
+		errors.New("ERR value is not an integer or out of range"),
+		errors.New("WRONGTYPE Operation against a key holding the wrong kind of value"),
+		errors.New("ERR Error running script (call to f_0123): user_script:1: bad argument"),
+	}
+	for _, reply := range replies {
+		for i := 0; i < 3; i++ {
+			breaker.Record(reply)
+		}
+		if state := breaker.State(); state != CircuitClosed {
+			t.Fatalf("Reply error %q should not open the breaker, got %s", reply, state)
+		}
+	}
+
+	outages := []error{
+		&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED},
+		context.DeadlineExceeded,
+		io.EOF,
+		redis.ErrClosed,
+		errors.New(poolTimeoutMessage),
+	}
+	for _, outage := range outages {
+		breaker := NewCircuitBreaker(2, time.Minute)
+		breaker.Record(outage)
+		breaker.Record(outage)
+		if state := breaker.State(); state != CircuitOpen {
+			t.Fatalf("Outage %v should count as a failure, got %s", outage, state)
+		}
+	}
+}
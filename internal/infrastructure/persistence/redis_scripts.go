@@ -0,0 +1,405 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/go-redis/redis/v8"
+)
+
+// Every script below receives the dedicated block key as its last key, checks
+// it before counting and sets it (value = reason) when the limit trips. They
+// return {state, remaining, retry after (µs), reset after (µs)} where state is
+// 1 when allowed, 0 when the limit was exceeded and -1 when blocked.
+
+// KEYS[1] = counter key, KEYS[2] = block key
+// ARGV[1] = limit, ARGV[2] = window (µs), ARGV[3] = block duration (ms), ARGV[4] = block reason, ARGV[5] = cost
+var incrementAndCheckScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local expiration = math.max(1, math.ceil(tonumber(ARGV[2]) / 1000))
+local block = tonumber(ARGV[3])
+local cost = tonumber(ARGV[5])
+
+local blockedFor = redis.call('PTTL', KEYS[2])
+if blockedFor > 0 then
+	return {-1, 0, blockedFor * 1000, blockedFor * 1000}
+end
+
+local count = redis.call('INCRBY', KEYS[1], cost)
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 then
+	redis.call('PEXPIRE', KEYS[1], expiration)
+	ttl = expiration
+end
+
+if count > limit then
+	redis.call('DECRBY', KEYS[1], cost)
+	if block > 0 then
+		redis.call('SET', KEYS[2], ARGV[4], 'PX', block)
+		return {0, 0, block * 1000, block * 1000}
+	end
+	return {0, 0, ttl * 1000, ttl * 1000}
+end
+return {1, limit - count, 0, ttl * 1000}
+`)
+
+// KEYS[1] = bucket key, KEYS[2] = block key
+// ARGV[1] = capacity, ARGV[2] = refill rate (tokens/s), ARGV[3] = block duration (ms), ARGV[4] = block reason, ARGV[5] = cost
+var takeTokenScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local block = tonumber(ARGV[3])
+local cost = tonumber(ARGV[5])
+
+local blockedFor = redis.call('PTTL', KEYS[2])
+if blockedFor > 0 then
+	return {-1, 0, blockedFor * 1000, blockedFor * 1000}
+end
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now
+
+tokens = math.min(capacity, tokens + (now - ts) / 1000 * rate)
+local allowed = 0
+local retryAfter = 0
+if tokens >= cost then
+	tokens = tokens - cost
+	allowed = 1
+elseif block > 0 then
+	redis.call('SET', KEYS[2], ARGV[4], 'PX', block)
+	retryAfter = block
+elseif rate > 0 then
+	retryAfter = math.ceil((cost - tokens) / rate * 1000)
+end
+
+local resetAfter = 0
+if rate > 0 then
+	resetAfter = math.ceil((capacity - tokens) / rate * 1000)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], resetAfter + 1000)
+return {allowed, math.floor(tokens), retryAfter * 1000, math.max(retryAfter, resetAfter) * 1000}
+`)
+
+// KEYS[1] = theoretical arrival time key (µs), KEYS[2] = block key
+// ARGV[1] = emission interval (µs), ARGV[2] = burst, ARGV[3] = block duration (ms), ARGV[4] = block reason, ARGV[5] = cost
+var updateTATScript = redis.NewScript(`
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local block = tonumber(ARGV[3])
+local cost = tonumber(ARGV[5])
+
+local blockedFor = redis.call('PTTL', KEYS[2])
+if blockedFor > 0 then
+	return {-1, 0, blockedFor * 1000, blockedFor * 1000}
+end
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+local tat = tonumber(redis.call('GET', KEYS[1])) or now
+if tat < now then
+	tat = now
+end
+
+local newTat = tat + cost * interval
+local allowAt = newTat - burst * interval
+if now < allowAt then
+	if block > 0 then
+		redis.call('SET', KEYS[2], ARGV[4], 'PX', block)
+		return {0, 0, block * 1000, block * 1000}
+	end
+	return {0, 0, allowAt - now, tat - now}
+end
+
+redis.call('SET', KEYS[1], string.format('%.0f', newTat), 'PX', math.max(1, math.ceil((newTat - now) / 1000)))
+return {1, math.floor((now - allowAt) / interval), 0, newTat - now}
+`)
+
+// KEYS[1] = log sorted set, KEYS[2] = block key
+// ARGV[1] = member, ARGV[2] = limit, ARGV[3] = window (µs), ARGV[4] = block duration (ms), ARGV[5] = block reason, ARGV[6] = cost
+var addToLogScript = redis.NewScript(`
+local limit = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+local block = tonumber(ARGV[4])
+local cost = tonumber(ARGV[6])
+
+local blockedFor = redis.call('PTTL', KEYS[2])
+if blockedFor > 0 then
+	return {-1, 0, blockedFor * 1000, blockedFor * 1000}
+end
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', string.format('%.0f', now - window))
+
+local count = redis.call('ZCARD', KEYS[1])
+if count + cost > limit then
+	if block > 0 then
+		redis.call('SET', KEYS[2], ARGV[5], 'PX', block)
+		return {0, 0, block * 1000, block * 1000}
+	end
+	local expiring = redis.call('ZRANGE', KEYS[1], count + cost - limit - 1, count + cost - limit - 1, 'WITHSCORES')
+	local retryAfter = window
+	if expiring[2] then
+		retryAfter = tonumber(expiring[2]) + window - now
+	end
+	return {0, 0, retryAfter, retryAfter}
+end
+
+for i = 1, cost do
+	redis.call('ZADD', KEYS[1], string.format('%.0f', now), ARGV[1] .. ':' .. i)
+end
+redis.call('PEXPIRE', KEYS[1], math.ceil(window / 1000))
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+return {1, limit - count - cost, 0, tonumber(oldest[2]) + window - now}
+`)
+
+// KEYS[1] = window counter hash, KEYS[2] = block key
+// ARGV[1] = limit, ARGV[2] = window (µs), ARGV[3] = block duration (ms), ARGV[4] = block reason, ARGV[5] = cost
+var incrementWindowCounterScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local block = tonumber(ARGV[3])
+local cost = tonumber(ARGV[5])
+local threshold = limit - cost + 1
+
+local blockedFor = redis.call('PTTL', KEYS[2])
+if blockedFor > 0 then
+	return {-1, 0, blockedFor * 1000, blockedFor * 1000}
+end
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+local current = math.floor(now / window)
+local currentField = string.format('%.0f', current)
+local previousField = string.format('%.0f', current - 1)
+local windowEnd = (current + 1) * window
+local elapsed = (now - current * window) / window
+
+local counts = redis.call('HMGET', KEYS[1], currentField, previousField)
+local currentCount = tonumber(counts[1]) or 0
+local previousCount = tonumber(counts[2]) or 0
+local estimate = previousCount * (1 - elapsed) + currentCount
+if estimate >= threshold then
+	if block > 0 then
+		redis.call('SET', KEYS[2], ARGV[4], 'PX', block)
+		return {0, 0, block * 1000, block * 1000}
+	end
+	local retryAfter
+	if threshold <= 0 then
+		retryAfter = windowEnd + window - now
+	elseif currentCount < threshold then
+		retryAfter = current * window + (1 - (threshold - currentCount) / previousCount) * window - now
+	else
+		retryAfter = windowEnd + math.max(0, 1 - threshold / currentCount) * window - now
+	end
+	return {0, 0, math.ceil(retryAfter) + 1, windowEnd - now}
+end
+
+redis.call('HINCRBY', KEYS[1], currentField, cost)
+for _, field in ipairs(redis.call('HKEYS', KEYS[1])) do
+	if field ~= currentField and field ~= previousField then
+		redis.call('HDEL', KEYS[1], field)
+	end
+end
+redis.call('PEXPIRE', KEYS[1], math.ceil(2 * window / 1000))
+return {1, math.max(0, math.floor(limit - estimate - cost)), 0, windowEnd - now}
+`)
+
+// KEYS[1] = block key
+// ARGV[1] = reason, ARGV[2] = duration (ms)
+var setBlockScript = redis.NewScript(`
+local duration = tonumber(ARGV[2])
+if redis.call('PTTL', KEYS[1]) < duration then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', duration)
+end
+return 1
+`)
+
+// KEYS[1] = block key, KEYS[2..n+1] = one fixed window counter per limit
+// ARGV[1] = block duration (ms), ARGV[2] = block reason, ARGV[3] = cost,
+// ARGV[2i+2] = limit i, ARGV[2i+3] = window i (µs)
+// return {state, tripped limit (0-based, -1 when none), retry after (µs),
+// remaining 1, reset after 1 (µs), ..., remaining n, reset after n (µs)}
+var incrementLimitsScript = redis.NewScript(`
+local block = tonumber(ARGV[1])
+local cost = tonumber(ARGV[3])
+local rules = #KEYS - 1
+local result = {1, -1, 0}
+
+local blockedFor = redis.call('PTTL', KEYS[1])
+if blockedFor > 0 then
+	result[1] = -1
+	result[3] = blockedFor * 1000
+end
+
+local counts, ttls, limits, windows = {}, {}, {}, {}
+local trippedTtl = -1
+for i = 1, rules do
+	limits[i] = tonumber(ARGV[2 * i + 2])
+	windows[i] = math.max(1, math.ceil(tonumber(ARGV[2 * i + 3]) / 1000))
+	counts[i] = tonumber(redis.call('GET', KEYS[i + 1])) or 0
+	ttls[i] = redis.call('PTTL', KEYS[i + 1])
+	if ttls[i] < 0 then
+		counts[i] = 0
+		ttls[i] = windows[i]
+	end
+	if result[1] == 1 and counts[i] + cost > limits[i] and ttls[i] > trippedTtl then
+		result[2] = i - 1
+		trippedTtl = ttls[i]
+	end
+end
+
+if result[2] >= 0 then
+	result[1] = 0
+	result[3] = trippedTtl * 1000
+	if block > 0 then
+		redis.call('SET', KEYS[1], ARGV[2], 'PX', block)
+		result[3] = block * 1000
+	end
+elseif result[1] == 1 then
+	for i = 1, rules do
+		counts[i] = redis.call('INCRBY', KEYS[i + 1], cost)
+		if redis.call('PTTL', KEYS[i + 1]) < 0 then
+			redis.call('PEXPIRE', KEYS[i + 1], windows[i])
+		end
+	end
+end
+
+for i = 1, rules do
+	table.insert(result, math.max(0, limits[i] - counts[i]))
+	table.insert(result, ttls[i] * 1000)
+end
+return result
+`)
+
+// KEYS[i] = quota counter for period i
+// ARGV[1] = cost, ARGV[2i] = limit i, ARGV[2i+1] = period reset (unix ms)
+// return {allowed, used 1, ..., used n}
+var consumeQuotaScript = redis.NewScript(`
+local cost = tonumber(ARGV[1])
+local allowed = 1
+local used = {}
+for i = 1, #KEYS do
+	used[i] = tonumber(redis.call('GET', KEYS[i])) or 0
+	if used[i] + cost > tonumber(ARGV[2 * i]) then
+		allowed = 0
+	end
+end
+
+if allowed == 1 then
+	for i = 1, #KEYS do
+		used[i] = redis.call('INCRBY', KEYS[i], cost)
+		redis.call('PEXPIREAT', KEYS[i], ARGV[2 * i + 1])
+	end
+end
+
+local result = {allowed}
+for i = 1, #KEYS do
+	table.insert(result, used[i])
+end
+return result
+`)
+
+// KEYS[1] = lease set
+// ARGV[1] = lease id, ARGV[2] = limit, ARGV[3] = lease duration (µs)
+// return {acquired, in flight}
+var acquireSlotScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+local lease = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+local inFlight = redis.call('ZCARD', KEYS[1])
+if inFlight >= tonumber(ARGV[2]) then
+	return {0, inFlight}
+end
+
+redis.call('ZADD', KEYS[1], string.format('%.0f', now + lease), ARGV[1])
+redis.call('PEXPIRE', KEYS[1], math.max(redis.call('PTTL', KEYS[1]), math.ceil(lease / 1000)))
+return {1, inFlight + 1}
+`)
+
+// KEYS[1] = lease set
+// ARGV[1] = lease id, ARGV[2] = lease duration (µs)
+// return 1 when the lease was renewed, 0 when it had already expired or been released
+var renewSlotScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+local lease = tonumber(ARGV[2])
+
+local expiresAt = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not expiresAt or tonumber(expiresAt) <= now then
+	return 0
+end
+
+redis.call('ZADD', KEYS[1], 'XX', string.format('%.0f', now + lease), ARGV[1])
+redis.call('PEXPIRE', KEYS[1], math.max(redis.call('PTTL', KEYS[1]), math.ceil(lease / 1000)))
+return 1
+`)
+
+func runScript(ctx context.Context, client *redis.Client, script *redis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	result, err := script.EvalSha(ctx, client, keys, args...).Result()
+	if err == nil || !strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		return result, err
+	}
+
+	if err := script.Load(ctx, client).Err(); err != nil {
+		return nil, err
+	}
+	return script.EvalSha(ctx, client, keys, args...).Result()
+}
+
+func scriptResult(result interface{}) (domain.StoreResult, error) {
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 4 {
+		return domain.StoreResult{}, fmt.Errorf("unexpected script result: %v", result)
+	}
+
+	ints := make([]int64, len(values))
+	for i, value := range values {
+		ints[i], _ = value.(int64)
+	}
+
+	return domain.StoreResult{
+		Allowed:    ints[0] == 1,
+		Blocked:    ints[0] == -1,
+		Remaining:  ints[1],
+		RetryAfter: time.Duration(ints[2]) * time.Microsecond,
+		ResetAfter: time.Duration(ints[3]) * time.Microsecond,
+	}, nil
+}
+
+func stackedScriptResult(result interface{}, rules int) (domain.StackedResult, error) {
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 3+2*rules {
+		return domain.StackedResult{}, fmt.Errorf("unexpected script result: %v", result)
+	}
+
+	ints := make([]int64, len(values))
+	for i, value := range values {
+		ints[i], _ = value.(int64)
+	}
+
+	stacked := domain.StackedResult{
+		Allowed:    ints[0] == 1,
+		Blocked:    ints[0] == -1,
+		Tripped:    int(ints[1]),
+		RetryAfter: time.Duration(ints[2]) * time.Microsecond,
+		Limits:     make([]domain.LimitResult, rules),
+	}
+	for i := range stacked.Limits {
+		stacked.Limits[i] = domain.LimitResult{
+			Remaining:  ints[3+2*i],
+			ResetAfter: time.Duration(ints[4+2*i]) * time.Microsecond,
+		}
+	}
+	return stacked, nil
+}
@@ -1,31 +1,364 @@
 package persistence
 
 import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/ankardo/Rate-Limiter/internal/domain"
 	"github.com/go-redis/redis/v8"
 )
 
+const (
+	blockKeyPrefix       = "block:"
+	concurrencyKeyPrefix = "concurrency:"
+	allowlistKey         = "access:allow"
+	denylistKey          = "access:deny"
+	apiKeyPrefix         = "apikey:"
+)
+
+type RedisStoreConfig struct {
+	Timeout time.Duration
+	// AdminTimeout bounds admin reads such as ListBlocks, which scan the whole
+	// keyspace and so get neither the request deadline nor the breaker.
+	AdminTimeout time.Duration
+	Breaker      *CircuitBreaker
+}
+
 type RedisStore struct {
-	client *redis.Client
+	client       *redis.Client
+	timeout      time.Duration
+	adminTimeout time.Duration
+	breaker      *CircuitBreaker
 }
 
 func NewRedisStore(client *redis.Client) *RedisStore {
-	return &RedisStore{client: client}
+	return NewRedisStoreWithConfig(client, RedisStoreConfig{})
+}
+
+func NewRedisStoreWithConfig(client *redis.Client, config RedisStoreConfig) *RedisStore {
+	return &RedisStore{client: client, timeout: config.Timeout, adminTimeout: config.AdminTimeout, breaker: config.Breaker}
 }
 
-func (r *RedisStore) Increment(key string) (int64, error) {
-	return r.client.Incr(r.client.Context(), key).Result()
+func (r *RedisStore) Increment(ctx context.Context, key string, cost int64) (int64, error) {
+	var count int64
+	err := r.call(ctx, func(ctx context.Context) (err error) {
+		count, err = r.client.IncrBy(ctx, key, cost).Result()
+		return err
+	})
+	return count, err
 }
 
-func (r *RedisStore) GetTTL(key string) (int64, error) {
-	duration, err := r.client.TTL(r.client.Context(), key).Result()
+func (r *RedisStore) GetTTL(ctx context.Context, key string) (int64, error) {
+	var duration time.Duration
+	err := r.call(ctx, func(ctx context.Context) (err error) {
+		duration, err = r.client.TTL(ctx, key).Result()
+		return err
+	})
 	if err != nil {
 		return 0, err
 	}
 	return int64(duration.Seconds()), nil
 }
 
-func (r *RedisStore) SetExpiration(key string, duration int64) error {
-	return r.client.Expire(r.client.Context(), key, time.Duration(duration)*time.Second).Err()
+func (r *RedisStore) SetExpiration(ctx context.Context, key string, duration int64) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		return r.client.Expire(ctx, key, time.Duration(duration)*time.Second).Err()
+	})
+}
+
+func (r *RedisStore) IncrementAndCheck(ctx context.Context, key string, limit, window, blockDuration, cost int64) (domain.StoreResult, error) {
+	return r.evalLimitScript(ctx, incrementAndCheckScript, key, key, limit, window, blockDuration*1000, domain.ReasonLimitExceeded, cost)
+}
+
+func (r *RedisStore) TakeToken(ctx context.Context, key string, capacity int64, refillPerSecond float64, blockDuration, cost int64) (domain.StoreResult, error) {
+	return r.evalLimitScript(ctx, takeTokenScript, "bucket:"+key, key, capacity, refillPerSecond, blockDuration*1000, domain.ReasonLimitExceeded, cost)
+}
+
+func (r *RedisStore) UpdateTAT(ctx context.Context, key string, emissionInterval, burst, blockDuration, cost int64) (domain.StoreResult, error) {
+	return r.evalLimitScript(ctx, updateTATScript, "gcra:"+key, key, emissionInterval, burst, blockDuration*1000, domain.ReasonLimitExceeded, cost)
+}
+
+func (r *RedisStore) AddToLog(ctx context.Context, key, member string, limit, window, blockDuration, cost int64) (domain.StoreResult, error) {
+	return r.evalLimitScript(ctx, addToLogScript, "log:"+key, key, member, limit, window, blockDuration*1000, domain.ReasonLimitExceeded, cost)
+}
+
+func (r *RedisStore) IncrementWindowCounter(ctx context.Context, key string, limit, window, blockDuration, cost int64) (domain.StoreResult, error) {
+	return r.evalLimitScript(ctx, incrementWindowCounterScript, "window:"+key, key, limit, window, blockDuration*1000, domain.ReasonLimitExceeded, cost)
+}
+
+func (r *RedisStore) IncrementLimits(ctx context.Context, key string, rules []domain.LimitRule, blockDuration, cost int64) (domain.StackedResult, error) {
+	keys := []string{blockKeyPrefix + key}
+	args := []interface{}{blockDuration * 1000, domain.ReasonLimitExceeded, cost}
+	for i, rule := range rules {
+		keys = append(keys, fmt.Sprintf("limit:%s:%d:%d", key, i, rule.Window.Milliseconds()))
+		args = append(args, rule.MaxRequests, rule.Window.Microseconds())
+	}
+
+	var result interface{}
+	err := r.call(ctx, func(ctx context.Context) (err error) {
+		result, err = runScript(ctx, r.client, incrementLimitsScript, keys, args...)
+		return err
+	})
+	if err != nil {
+		return domain.StackedResult{}, err
+	}
+	return stackedScriptResult(result, len(rules))
+}
+
+func (r *RedisStore) ConsumeQuota(ctx context.Context, key string, windows []domain.QuotaWindow, cost int64) (domain.QuotaResult, error) {
+	keys := make([]string, len(windows))
+	args := make([]interface{}, 0, 2*len(windows)+1)
+	args = append(args, cost)
+	for i, window := range windows {
+		keys[i] = quotaKey(key, window)
+		args = append(args, window.Limit, window.ResetAt.UnixMilli())
+	}
+
+	var result interface{}
+	err := r.call(ctx, func(ctx context.Context) (err error) {
+		result, err = runScript(ctx, r.client, consumeQuotaScript, keys, args...)
+		return err
+	})
+	if err != nil {
+		return domain.QuotaResult{}, err
+	}
+	values, ok := result.([]interface{})
+	if !ok || len(values) != len(windows)+1 {
+		return domain.QuotaResult{}, fmt.Errorf("unexpected script result: %v", result)
+	}
+
+	quota := domain.QuotaResult{Used: make([]int64, len(windows))}
+	allowed, _ := values[0].(int64)
+	quota.Allowed = allowed == 1
+	for i := range windows {
+		quota.Used[i], _ = values[i+1].(int64)
+	}
+	return quota, nil
+}
+
+func (r *RedisStore) QuotaUsed(ctx context.Context, key string, windows []domain.QuotaWindow) ([]int64, error) {
+	used := make([]int64, len(windows))
+	if len(windows) == 0 {
+		return used, nil
+	}
+
+	keys := make([]string, len(windows))
+	for i, window := range windows {
+		keys[i] = quotaKey(key, window)
+	}
+	var values []interface{}
+	err := r.call(ctx, func(ctx context.Context) (err error) {
+		values, err = r.client.MGet(ctx, keys...).Result()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		if text, ok := value.(string); ok {
+			used[i], _ = strconv.ParseInt(text, 10, 64)
+		}
+	}
+	return used, nil
+}
+
+func (r *RedisStore) SetBlock(ctx context.Context, key, reason string, duration int64) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		_, err := runScript(ctx, r.client, setBlockScript, []string{blockKeyPrefix + key}, reason, duration*1000)
+		return err
+	})
+}
+
+func (r *RedisStore) BlockedFor(ctx context.Context, key string) (time.Duration, error) {
+	var ttl time.Duration
+	err := r.call(ctx, func(ctx context.Context) (err error) {
+		ttl, err = r.client.PTTL(ctx, blockKeyPrefix+key).Result()
+		return err
+	})
+	if err != nil || ttl < 0 {
+		return 0, err
+	}
+	return ttl, nil
+}
+
+func (r *RedisStore) ListBlocks(ctx context.Context) ([]domain.Block, error) {
+	if r.adminTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.adminTimeout)
+		defer cancel()
+	}
+
+	var blocks []domain.Block
+	var cursor uint64
+	for {
+		blockKeys, next, err := r.client.Scan(ctx, cursor, blockKeyPrefix+"*", 100).Result()
+		if err != nil {
+			return nil, err
+		}
+		page, err := r.readBlocks(ctx, blockKeys)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, page...)
+		if cursor = next; cursor == 0 {
+			return blocks, nil
+		}
+	}
+}
+
+// readBlocks fetches the reason and TTL of one SCAN page in a single round
+// trip, skipping blocks that expired since the scan.
+func (r *RedisStore) readBlocks(ctx context.Context, blockKeys []string) ([]domain.Block, error) {
+	if len(blockKeys) == 0 {
+		return nil, nil
+	}
+	reasons := make([]*redis.StringCmd, len(blockKeys))
+	ttls := make([]*redis.DurationCmd, len(blockKeys))
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, blockKey := range blockKeys {
+			reasons[i] = pipe.Get(ctx, blockKey)
+			ttls[i] = pipe.PTTL(ctx, blockKey)
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	blocks := make([]domain.Block, 0, len(blockKeys))
+	for i, blockKey := range blockKeys {
+		reason, err := reasons[i].Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		ttl, err := ttls[i].Result()
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, domain.Block{
+			Key:       strings.TrimPrefix(blockKey, blockKeyPrefix),
+			Reason:    reason,
+			ExpiresIn: ttl,
+		})
+	}
+	return blocks, nil
+}
+
+func (r *RedisStore) DeleteBlock(ctx context.Context, key string) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		return r.client.Del(ctx, blockKeyPrefix+key).Err()
+	})
+}
+
+func (r *RedisStore) AcquireSlot(ctx context.Context, key, id string, limit, lease int64) (domain.ConcurrencyResult, error) {
+	var result interface{}
+	err := r.call(ctx, func(ctx context.Context) (err error) {
+		result, err = runScript(ctx, r.client, acquireSlotScript, []string{concurrencyKeyPrefix + key}, id, limit, lease)
+		return err
+	})
+	if err != nil {
+		return domain.ConcurrencyResult{}, err
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return domain.ConcurrencyResult{}, fmt.Errorf("unexpected script result: %v", result)
+	}
+	acquired, _ := values[0].(int64)
+	inFlight, _ := values[1].(int64)
+	return domain.ConcurrencyResult{Acquired: acquired == 1, InFlight: inFlight}, nil
+}
+
+func (r *RedisStore) RenewSlot(ctx context.Context, key, id string, lease int64) (bool, error) {
+	var result interface{}
+	err := r.call(ctx, func(ctx context.Context) (err error) {
+		result, err = runScript(ctx, r.client, renewSlotScript, []string{concurrencyKeyPrefix + key}, id, lease)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	renewed, _ := result.(int64)
+	return renewed == 1, nil
+}
+
+func (r *RedisStore) ReleaseSlot(ctx context.Context, key, id string) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		return r.client.ZRem(ctx, concurrencyKeyPrefix+key, id).Err()
+	})
+}
+
+func (r *RedisStore) AccessNetworks(ctx context.Context) ([]string, []string, error) {
+	var allow, deny *redis.StringSliceCmd
+	err := r.call(ctx, func(ctx context.Context) error {
+		pipe := r.client.Pipeline()
+		allow = pipe.SMembers(ctx, allowlistKey)
+		deny = pipe.SMembers(ctx, denylistKey)
+		_, err := pipe.Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return allow.Val(), deny.Val(), nil
+}
+
+func (r *RedisStore) APIKey(ctx context.Context, hash string) (domain.APIKeyRecord, bool, error) {
+	var fields map[string]string
+	err := r.call(ctx, func(ctx context.Context) (err error) {
+		fields, err = r.client.HGetAll(ctx, apiKeyPrefix+hash).Result()
+		return err
+	})
+	if err != nil {
+		return domain.APIKeyRecord{}, false, err
+	}
+	if len(fields) == 0 {
+		return domain.APIKeyRecord{}, false, nil
+	}
+	return domain.APIKeyRecord{
+		Owner:  fields["owner"],
+		Plan:   fields["plan"],
+		Limits: fields["limits"],
+	}, true, nil
+}
+
+func (r *RedisStore) call(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r.breaker != nil {
+		if err := r.breaker.Allow(); err != nil {
+			return err
+		}
+	}
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	err := fn(ctx)
+	if r.breaker != nil {
+		r.breaker.Record(err)
+	}
+	return err
+}
+
+func quotaKey(key string, window domain.QuotaWindow) string {
+	return fmt.Sprintf("quota:%s:%s:%s", key, window.Period, window.Start.Format("20060102"))
+}
+
+func (r *RedisStore) evalLimitScript(ctx context.Context, script *redis.Script, key, blockedKey string, args ...interface{}) (domain.StoreResult, error) {
+	var result interface{}
+	err := r.call(ctx, func(ctx context.Context) (err error) {
+		result, err = runScript(ctx, r.client, script, []string{key, blockKeyPrefix + blockedKey}, args...)
+		return err
+	})
+	if err != nil {
+		return domain.StoreResult{}, err
+	}
+	return scriptResult(result)
 }
@@ -0,0 +1,87 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// hungRedis accepts connections and never answers, like a stalled Redis.
+func hungRedis(t *testing.T) *redis.Client {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(io.Discard, conn)
+		}
+	}()
+
+	client := redis.NewClient(&redis.Options{Addr: listener.Addr().String(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestRedisStore_TimeoutTripsBreaker(t *testing.T) {
+	client := hungRedis(t)
+
+	breaker := NewCircuitBreaker(2, time.Minute)
+	store := NewRedisStoreWithConfig(client, RedisStoreConfig{Timeout: 50 * time.Millisecond, Breaker: breaker})
+
+	for i := 1; i <= 2; i++ {
+		start := time.Now()
+		_, err := store.Increment(context.Background(), "key", 1)
+		if err == nil {
+			t.Fatalf("Call %d: expected a hung Redis to time out", i)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("Call %d: expected the deadline to cut the call short, took %v", i, elapsed)
+		}
+	}
+
+	if state := breaker.State(); state != CircuitOpen {
+		t.Fatalf("Expected repeated timeouts to open the breaker, got %s", state)
+	}
+	if _, err := store.Increment(context.Background(), "key", 1); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected an open breaker to fail fast, got %v", err)
+	}
+}
+
+func TestRedisStore_ListBlocksBypassesBreaker(t *testing.T) {
+	breaker := NewCircuitBreaker(1, time.Minute)
+	store := NewRedisStoreWithConfig(hungRedis(t), RedisStoreConfig{
+		Timeout:      time.Millisecond,
+		AdminTimeout: 50 * time.Millisecond,
+		Breaker:      breaker,
+	})
+
+	start := time.Now()
+	if _, err := store.ListBlocks(context.Background()); err == nil {
+		t.Fatalf("Expected a hung Redis to time out")
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("Expected ListBlocks to use the admin deadline, took %v", elapsed)
+	}
+	if state := breaker.State(); state != CircuitClosed {
+		t.Fatalf("Expected admin reads to stay out of the breaker, got %s", state)
+	}
+
+	store.Increment(context.Background(), "key", 1)
+	if state := breaker.State(); state != CircuitOpen {
+		t.Fatalf("Expected a request timeout to open the breaker, got %s", state)
+	}
+	if _, err := store.ListBlocks(context.Background()); errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected admin reads to reach Redis with the breaker open")
+	}
+}
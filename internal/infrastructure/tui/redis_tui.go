@@ -155,7 +155,8 @@ func updateEnvTable(envTable *tview.Table) {
 		{"MAX_REQUESTS_PER_SECOND", os.Getenv("MAX_REQUESTS_PER_SECOND")},
 		{"REDIS_ADDR", os.Getenv("REDIS_ADDR")},
 		{"REDIS_PASSWORD", os.Getenv("REDIS_PASSWORD")},
-		{"TTL_EXPIRATION_SECONDS", os.Getenv("TTL_EXPIRATION_SECONDS")},
+		{"RATE_LIMIT_WINDOW", os.Getenv("RATE_LIMIT_WINDOW")},
+		{"TOKEN_RATE_LIMIT_WINDOW", os.Getenv("TOKEN_RATE_LIMIT_WINDOW")},
 	}
 
 	envTable.Clear()
@@ -194,7 +195,9 @@ func updateRedisTable(ctx context.Context, client *redis.Client, redisTable *tvi
 		redisTable.SetCell(i+1, 0, tview.NewTableCell(key).SetAlign(tview.AlignLeft))
 		redisTable.SetCell(i+1, 1, tview.NewTableCell(count).SetAlign(tview.AlignCenter))
 		redisTable.SetCell(i+1, 2, tview.NewTableCell(formatTTL(ttl)).SetAlign(tview.AlignCenter))
-		if strings.HasPrefix(key, "token:") {
+		if strings.HasPrefix(key, "block:") {
+			redisTable.SetCell(i+1, 3, tview.NewTableCell("Block").SetAlign(tview.AlignCenter))
+		} else if strings.HasPrefix(key, "token:") {
 			redisTable.SetCell(i+1, 3, tview.NewTableCell("Token").SetAlign(tview.AlignCenter))
 		} else {
 			redisTable.SetCell(i+1, 3, tview.NewTableCell("IP").SetAlign(tview.AlignCenter))
@@ -0,0 +1,95 @@
+package webserver
+
+import (
+	"crypto/subtle"
+	"expvar"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/app/middleware"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/ankardo/Rate-Limiter/internal/dto"
+)
+
+const adminTokenHeader = "X-Admin-Token"
+
+func registerAdminRoutes(r chi.Router, config RouterConfig) {
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(requireAdminToken(config.AdminToken))
+		r.Handle("/metrics", expvar.Handler())
+
+		if config.BlockManager != nil {
+			registerBlockRoutes(r, config.BlockManager)
+		}
+		if config.Quota != nil {
+			r.Get("/quotas", func(w http.ResponseWriter, r *http.Request) {
+				key := r.URL.Query().Get("key")
+				if key == "" {
+					writeJSON(w, http.StatusBadRequest, dto.ErrorResponse{Message: "missing key"})
+					return
+				}
+				writeQuotaUsage(w, r, config.Quota, key)
+			})
+			r.Get("/quotas/{token}", func(w http.ResponseWriter, r *http.Request) {
+				writeQuotaUsage(w, r, config.Quota, middleware.TokenKey(chi.URLParam(r, "token"), config.RegisteredKeys))
+			})
+		}
+	})
+}
+
+func registerBlockRoutes(r chi.Router, blockManager domain.BlockManager) {
+	r.Get("/blocks", func(w http.ResponseWriter, r *http.Request) {
+		blocks, err := blockManager.ListBlocks(r.Context())
+		if err != nil {
+			logger.Error("Failed to list blocks", err)
+			writeJSON(w, http.StatusInternalServerError, dto.ErrorResponse{Message: "failed to list blocks"})
+			return
+		}
+
+		response := make([]dto.BlockResponse, 0, len(blocks))
+		for _, block := range blocks {
+			response = append(response, dto.BlockResponse{
+				Key:              block.Key,
+				Reason:           block.Reason,
+				ExpiresInSeconds: int64(block.ExpiresIn.Seconds()),
+			})
+		}
+		writeJSON(w, http.StatusOK, response)
+	})
+
+	r.Delete("/blocks/*", func(w http.ResponseWriter, r *http.Request) {
+		key, err := url.PathUnescape(chi.URLParam(r, "*"))
+		if err != nil || key == "" {
+			writeJSON(w, http.StatusBadRequest, dto.ErrorResponse{Message: "invalid block key"})
+			return
+		}
+		if err := blockManager.LiftBlock(r.Context(), key); err != nil {
+			logger.Error("Failed to lift block", err, zap.String("key", key))
+			writeJSON(w, http.StatusInternalServerError, dto.ErrorResponse{Message: "failed to lift block"})
+			return
+		}
+
+		logger.Info("Block lifted", zap.String("key", key))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func validAdminToken(r *http.Request, adminToken string) bool {
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get(adminTokenHeader)), []byte(adminToken)) == 1
+}
+
+func requireAdminToken(adminToken string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !validAdminToken(r, adminToken) {
+				writeJSON(w, http.StatusUnauthorized, dto.ErrorResponse{Message: "invalid admin token"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
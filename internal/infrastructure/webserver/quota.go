@@ -0,0 +1,59 @@
+package webserver
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/app/middleware"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/ankardo/Rate-Limiter/internal/dto"
+)
+
+func registerQuotaRoutes(r chi.Router, quota domain.QuotaLimiter, keys middleware.KeyExtractor) {
+	if keys == nil {
+		keys = middleware.DefaultKeyExtractor(nil)
+	}
+	r.Get("/quota", func(w http.ResponseWriter, r *http.Request) {
+		key, err := middleware.ExtractRequestKey(keys, r)
+		var keyErr *middleware.KeyError
+		switch {
+		case errors.As(err, &keyErr):
+			writeJSON(w, keyErr.Status, dto.ErrorResponse{Message: keyErr.Message})
+			return
+		case errors.Is(err, middleware.ErrKeyNotFound) || (err == nil && !key.IsToken):
+			writeJSON(w, http.StatusBadRequest, dto.ErrorResponse{Message: "quotas apply to API keys and tokens only"})
+			return
+		case err != nil:
+			logger.Error("Failed to resolve the quota key", err)
+			writeJSON(w, http.StatusServiceUnavailable, dto.ErrorResponse{Message: "failed to resolve the API key"})
+			return
+		}
+		writeQuotaUsage(w, r, quota, key.Value)
+	})
+}
+
+func writeQuotaUsage(w http.ResponseWriter, r *http.Request, quota domain.QuotaLimiter, token string) {
+	usage, err := quota.Usage(r.Context(), token)
+	if err != nil {
+		logger.Error("Failed to read quota usage", err, zap.String("token", token))
+		writeJSON(w, http.StatusInternalServerError, dto.ErrorResponse{Message: "failed to read quota usage"})
+		return
+	}
+
+	response := dto.QuotaResponse{Token: token, Quotas: make([]dto.QuotaUsageResponse, 0, len(usage))}
+	for _, period := range usage {
+		response.Quotas = append(response.Quotas, dto.QuotaUsageResponse{
+			Period:    period.Period,
+			Limit:     period.Limit,
+			Used:      period.Used,
+			Remaining: period.Remaining,
+			ResetAt:   period.ResetAt.UTC().Format(time.RFC3339),
+		})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
@@ -3,18 +3,68 @@ package webserver
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 
 	"github.com/ankardo/Rate-Limiter/config/logger"
+	"github.com/ankardo/Rate-Limiter/internal/app/middleware"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
 )
 
-func NewRouter(rateLimiterMiddleware func(http.Handler) http.Handler) http.Handler {
-	r := chi.NewRouter()
+type RouterConfig struct {
+	BlockManager domain.BlockManager
+	Quota        domain.QuotaLimiter
+	Keys         middleware.KeyExtractor
+	// RegisteredKeys reports that Keys resolves API keys through a registry,
+	// so their counters are stored under the key hash.
+	RegisteredKeys bool
+	AdminToken     string
+}
+
+func NewRouter(rateLimiterMiddleware func(http.Handler) http.Handler, config RouterConfig) http.Handler {
+	router := chi.NewRouter()
+	router.Use(exceptAdmins(config.AdminToken, rateLimiterMiddleware))
 
-	r.Use(rateLimiterMiddleware)
+	if config.AdminToken != "" {
+		registerAdminRoutes(router, config)
+	}
+	registerPublicRoutes(router)
+	if config.Quota != nil {
+		registerQuotaRoutes(router, config.Quota, config.Keys)
+	}
 
+	return router
+}
+
+// exceptAdmins limits every request at the root, so unknown paths, 404/405
+// responses and failed admin logins spend the same budget as real routes. Only
+// requests carrying a valid admin token to a registered admin route skip it, so
+// operators can still lift a block on their own address while the token cannot
+// be brute-forced.
+func exceptAdmins(adminToken string, limit func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		limited := limit(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if adminToken != "" && validAdminToken(r, adminToken) && isAdminRoute(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			limited.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isAdminRoute(r *http.Request) bool {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil || !strings.HasPrefix(r.URL.Path, "/admin/") {
+		return false
+	}
+	return rctx.Routes.Match(chi.NewRouteContext(), r.Method, r.URL.Path)
+}
+
+func registerPublicRoutes(r chi.Router) {
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		logger.Info("Request received", zap.String("path", r.URL.Path))
 		w.Write([]byte("Welcome to the Rate Limiter!"))
@@ -46,8 +96,6 @@ func NewRouter(rateLimiterMiddleware func(http.Handler) http.Handler) http.Handl
 		logger.Info("Token request processed", zap.String("token", token))
 		writeJSON(w, http.StatusOK, map[string]string{"message": "Token request handled successfully", "token": token})
 	})
-
-	return r
 }
 
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -65,6 +65,10 @@ func TestRateLimiterE2E(t *testing.T) {
 
 				validateResponse(t, i+1, res.StatusCode, tt.expectStatus[i])
 
+				if res.StatusCode == http.StatusTooManyRequests && res.Header.Get("Retry-After") == "" {
+					t.Fatalf("Iteration %d failed: expected Retry-After header on 429 response", i+1)
+				}
+
 				if res.StatusCode == http.StatusOK {
 					var response map[string]string
 					err = json.NewDecoder(res.Body).Decode(&response)
@@ -3,7 +3,9 @@ package integration
 import (
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
 	"github.com/ankardo/Rate-Limiter/internal/app/middleware"
@@ -11,11 +13,24 @@ import (
 )
 
 func TestMemoryRateLimiterMiddlewareIntegration(t *testing.T) {
+	var mu sync.Mutex
+	now := time.Unix(1700000000, 0)
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
 	memoryLimiter := limiter.NewMemoryRateLimiter(domain.LimiterConfig{
 		MaxRequests:      5,
 		TokenMaxRequests: 10,
 		BlockDuration:    3,
+		Clock: func() time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+			return now
+		},
 	})
+	defer memoryLimiter.Close()
 
 	handler := middleware.RateLimiterMiddleware(memoryLimiter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -51,7 +66,7 @@ func TestMemoryRateLimiterMiddlewareIntegration(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			for i := 0; i < tt.iterations; i++ {
+			doRequest := func() *http.Response {
 				req, _ := http.NewRequest("GET", server.URL, nil)
 				if tt.headerKey != "" {
 					req.Header.Set(tt.headerKey, tt.key)
@@ -61,13 +76,29 @@ func TestMemoryRateLimiterMiddlewareIntegration(t *testing.T) {
 
 				res, err := client.Do(req)
 				if err != nil {
-					t.Fatalf("Request %d failed: %v", i+1, err)
+					t.Fatalf("Request failed: %v", err)
 				}
+				res.Body.Close()
+				return res
+			}
+
+			for i := 0; i < tt.iterations; i++ {
+				res := doRequest()
 
 				if res.StatusCode != tt.expectStatus[i] {
 					t.Fatalf("Iteration %d failed: expected %d, got %d", i+1, tt.expectStatus[i], res.StatusCode)
 				}
 			}
+
+			advance(1100 * time.Millisecond)
+			if res := doRequest(); res.StatusCode != http.StatusTooManyRequests {
+				t.Fatalf("Request within block duration: expected 429, got %d", res.StatusCode)
+			}
+
+			advance(2 * time.Second)
+			if res := doRequest(); res.StatusCode != http.StatusOK {
+				t.Fatalf("Request after block duration: expected 200, got %d", res.StatusCode)
+			}
 		})
 	}
 }
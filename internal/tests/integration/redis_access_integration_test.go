@@ -0,0 +1,56 @@
+package integration
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"testing"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/access"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/ankardo/Rate-Limiter/internal/infrastructure/persistence"
+)
+
+func TestRedisAccessLists(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	client, err := persistence.NewRedisClient(ctx, redisAddr, "")
+	if err != nil {
+		t.Fatalf("Failed to connect to Redis at %v: %v", redisAddr, err)
+	}
+	defer client.Close()
+
+	if err := client.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("Failed to flush Redis: %v", err)
+	}
+
+	store := persistence.NewRedisStore(client)
+	accessList := access.NewStoreAccessList(store, nil, nil, 0)
+	defer accessList.Close()
+
+	addr := netip.MustParseAddr("203.0.113.7")
+	if got := accessList.Access(addr); got != "" {
+		t.Fatalf("Expected no access list entry, got %q", got)
+	}
+
+	if err := client.SAdd(ctx, "access:deny", "203.0.113.0/24").Err(); err != nil {
+		t.Fatalf("Failed to add denied network: %v", err)
+	}
+	if err := client.SAdd(ctx, "access:allow", "10.0.0.0/8").Err(); err != nil {
+		t.Fatalf("Failed to add allowed network: %v", err)
+	}
+	if err := accessList.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh access lists: %v", err)
+	}
+
+	if got := accessList.Access(addr); got != domain.AccessDeny {
+		t.Fatalf("Expected networks added to Redis to be denied, got %q", got)
+	}
+	if got := accessList.Access(netip.MustParseAddr("10.9.8.7")); got != domain.AccessAllow {
+		t.Fatalf("Expected networks added to Redis to be allowed, got %q", got)
+	}
+}
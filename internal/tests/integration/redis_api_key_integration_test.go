@@ -0,0 +1,46 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/apikey"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/ankardo/Rate-Limiter/internal/infrastructure/persistence"
+)
+
+func TestRedisAPIKeyRegistry(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	client, err := persistence.NewRedisClient(ctx, redisAddr, "")
+	if err != nil {
+		t.Fatalf("Failed to connect to Redis at %v: %v", redisAddr, err)
+	}
+	defer client.Close()
+
+	if err := client.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("Failed to flush Redis: %v", err)
+	}
+
+	registry := apikey.NewRegistry(nil, persistence.NewRedisStore(client))
+	if _, found, err := registry.Lookup(ctx, "abc"); err != nil || found {
+		t.Fatalf("Expected the key to be unknown, got %v (%v)", found, err)
+	}
+
+	if err := client.HSet(ctx, "apikey:"+apikey.HashKey("abc"), "owner", "acme", "plan", "pro", "limits", "50/s").Err(); err != nil {
+		t.Fatalf("Failed to register API key: %v", err)
+	}
+	key, found, err := registry.Lookup(ctx, "abc")
+	if err != nil || !found {
+		t.Fatalf("Expected the key to be registered, got %v (%v)", found, err)
+	}
+	if key.Owner != "acme" || key.Plan != "pro" || len(key.Limits) != 1 || key.Limits[0] != (domain.LimitRule{MaxRequests: 50, Window: time.Second}) {
+		t.Fatalf("Unexpected API key: %+v", key)
+	}
+}
@@ -0,0 +1,97 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/ankardo/Rate-Limiter/internal/infrastructure/persistence"
+)
+
+func TestRedisAtomicIncrementConcurrentTTL(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	client, err := persistence.NewRedisClient(ctx, redisAddr, "")
+	if err != nil {
+		t.Fatalf("Failed to connect to Redis at %v: %v", redisAddr, err)
+	}
+	defer client.Close()
+
+	if err := client.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("Failed to flush Redis: %v", err)
+	}
+	if err := client.ScriptFlush(ctx).Err(); err != nil {
+		t.Fatalf("Failed to flush Redis scripts: %v", err)
+	}
+
+	store := persistence.NewRedisStore(client)
+	rateLimiter := limiter.NewRedisRateLimiter(store, domain.LimiterConfig{
+		MaxRequests:      20,
+		TokenMaxRequests: 20,
+		BlockDuration:    10,
+		Window:           30 * time.Second,
+	})
+
+	const keys = 20
+	const workers = 10
+	const requestsPerWorker = 10
+
+	var allowed [keys]int64
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for k := 0; k < keys; k++ {
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(k int) {
+				defer wg.Done()
+				for i := 0; i < requestsPerWorker; i++ {
+					ok, err := rateLimiter.AllowRequest(ctx, fmt.Sprintf("10.1.0.%d", k), false)
+					if err != nil {
+						t.Errorf("AllowRequest failed: %v", err)
+						return
+					}
+					if ok {
+						mu.Lock()
+						allowed[k]++
+						mu.Unlock()
+					}
+				}
+			}(k)
+		}
+	}
+	wg.Wait()
+
+	for k := 0; k < keys; k++ {
+		key := fmt.Sprintf("ip:10.1.0.%d", k)
+		ttl, err := client.TTL(ctx, key).Result()
+		if err != nil {
+			t.Fatalf("Failed to get TTL for %s: %v", key, err)
+		}
+		if ttl <= 0 {
+			t.Fatalf("Key %s lost its TTL under concurrent load: %v", key, ttl)
+		}
+
+		count, err := client.Get(ctx, key).Int64()
+		if err != nil {
+			t.Fatalf("Failed to get count for %s: %v", key, err)
+		}
+		if count != 20 {
+			t.Fatalf("Key %s: denied requests should not be counted, expected count 20, got %d", key, count)
+		}
+		if blockTTL, err := client.TTL(ctx, "block:"+key).Result(); err != nil || blockTTL <= 0 {
+			t.Fatalf("Key %s: expected a dedicated block key, got TTL %v (%v)", key, blockTTL, err)
+		}
+		if allowed[k] != 20 {
+			t.Fatalf("Key %s: expected exactly 20 allowed requests, got %d", key, allowed[k])
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/ankardo/Rate-Limiter/internal/infrastructure/persistence"
+)
+
+func TestRedisDedicatedBlockKeys(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	client, err := persistence.NewRedisClient(ctx, redisAddr, "")
+	if err != nil {
+		t.Fatalf("Failed to connect to Redis at %v: %v", redisAddr, err)
+	}
+	defer client.Close()
+
+	if err := client.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("Failed to flush Redis: %v", err)
+	}
+
+	store := persistence.NewRedisStore(client)
+	rateLimiter := limiter.NewRedisRateLimiter(store, domain.LimiterConfig{
+		MaxRequests:      2,
+		TokenMaxRequests: 5,
+		BlockDuration:    10,
+		Window:           time.Second,
+	})
+
+	for i := 0; i < 3; i++ {
+		rateLimiter.AllowRequest(ctx, "192.168.1.1", false)
+	}
+
+	reason, err := client.Get(ctx, "block:ip:192.168.1.1").Result()
+	if err != nil || reason != domain.ReasonLimitExceeded {
+		t.Fatalf("Expected block key with reason %q, got %q (%v)", domain.ReasonLimitExceeded, reason, err)
+	}
+	counterTTL, err := client.PTTL(ctx, "ip:192.168.1.1").Result()
+	if err != nil || counterTTL > time.Second {
+		t.Fatalf("The counter should keep its window TTL, got %v (%v)", counterTTL, err)
+	}
+
+	if err := rateLimiter.BlockKey(ctx, "ip:192.168.1.1", 2); err != nil {
+		t.Fatalf("Failed to block key: %v", err)
+	}
+	if err := rateLimiter.BlockKey(ctx, "token:abc", 30); err != nil {
+		t.Fatalf("Failed to block key: %v", err)
+	}
+
+	blocks, err := rateLimiter.ListBlocks(ctx)
+	if err != nil || len(blocks) != 2 {
+		t.Fatalf("Expected 2 blocks, got %+v (%v)", blocks, err)
+	}
+	byKey := map[string]domain.Block{}
+	for _, block := range blocks {
+		byKey[block.Key] = block
+	}
+	if block := byKey["ip:192.168.1.1"]; block.Reason != domain.ReasonLimitExceeded || block.ExpiresIn <= 2*time.Second {
+		t.Fatalf("A shorter manual block should not shorten the existing one, got %+v", block)
+	}
+	if block := byKey["token:abc"]; block.Reason != domain.BlockReasonManual || block.ExpiresIn <= 0 {
+		t.Fatalf("Unexpected manual block: %+v", block)
+	}
+
+	if allowed, _ := rateLimiter.AllowRequest(ctx, "abc", true); allowed {
+		t.Fatalf("Manually blocked token should be denied")
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	decision, err := rateLimiter.Evaluate(ctx, "192.168.1.1", false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decision.Allowed || decision.Reason != domain.ReasonBlocked {
+		t.Fatalf("Block should outlive the counter window, got %+v", decision)
+	}
+
+	if err := rateLimiter.LiftBlock(ctx, "ip:192.168.1.1"); err != nil {
+		t.Fatalf("Failed to lift block: %v", err)
+	}
+	if allowed, _ := rateLimiter.AllowRequest(ctx, "192.168.1.1", false); !allowed {
+		t.Fatalf("Request should be allowed once the block is lifted")
+	}
+}
+
+func TestRedisListBlocksAcrossScanPages(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	client, err := persistence.NewRedisClient(ctx, redisAddr, "")
+	if err != nil {
+		t.Fatalf("Failed to connect to Redis at %v: %v", redisAddr, err)
+	}
+	defer client.Close()
+
+	if err := client.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("Failed to flush Redis: %v", err)
+	}
+
+	store := persistence.NewRedisStore(client)
+	for i := 0; i < 250; i++ {
+		if err := store.SetBlock(ctx, fmt.Sprintf("ip:10.0.%d.%d", i/100, i%100), domain.BlockReasonManual, 60); err != nil {
+			t.Fatalf("Failed to block key: %v", err)
+		}
+	}
+	client.Set(ctx, "ip:10.0.0.1", 1, time.Minute)
+
+	blocks, err := store.ListBlocks(ctx)
+	if err != nil || len(blocks) != 250 {
+		t.Fatalf("Expected 250 blocks, got %d (%v)", len(blocks), err)
+	}
+	for _, block := range blocks {
+		if block.Reason != domain.BlockReasonManual || block.ExpiresIn <= 0 || block.ExpiresIn > time.Minute {
+			t.Fatalf("Unexpected block: %+v", block)
+		}
+	}
+}
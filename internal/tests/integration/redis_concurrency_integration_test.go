@@ -0,0 +1,81 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/ankardo/Rate-Limiter/internal/infrastructure/persistence"
+)
+
+func TestRedisConcurrencyLeases(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	client, err := persistence.NewRedisClient(ctx, redisAddr, "")
+	if err != nil {
+		t.Fatalf("Failed to connect to Redis at %v: %v", redisAddr, err)
+	}
+	defer client.Close()
+
+	if err := client.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("Failed to flush Redis: %v", err)
+	}
+
+	config := domain.LimiterConfig{MaxConcurrent: 2, LeaseDuration: 500 * time.Millisecond}
+	store := persistence.NewRedisStore(client)
+	concurrencyLimiter := limiter.NewRedisConcurrencyLimiter(store, config)
+	crashedInstance := limiter.NewRedisConcurrencyLimiter(store, config)
+
+	if decision, _, err := crashedInstance.Acquire(ctx, "192.168.1.1", false); err != nil || !decision.Allowed {
+		t.Fatalf("Expected the first slot to be acquired, got %+v (%v)", decision, err)
+	}
+	decision, lease, err := concurrencyLimiter.Acquire(ctx, "192.168.1.1", false)
+	if err != nil || !decision.Allowed || decision.Remaining != 0 {
+		t.Fatalf("Expected the second slot to be acquired, got %+v (%v)", decision, err)
+	}
+	if decision, _, _ := concurrencyLimiter.Acquire(ctx, "192.168.1.1", false); decision.Allowed {
+		t.Fatalf("Expected the third in-flight request to be denied, got %+v", decision)
+	}
+	if inFlight, _ := client.ZCard(ctx, "concurrency:ip:192.168.1.1").Result(); inFlight != 2 {
+		t.Fatalf("Expected 2 leases in Redis, got %d", inFlight)
+	}
+
+	if err := concurrencyLimiter.Release(ctx, lease); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decision, _, _ := concurrencyLimiter.Acquire(ctx, "192.168.1.1", false); !decision.Allowed {
+		t.Fatalf("Expected the released slot to be reused, got %+v", decision)
+	}
+
+	time.Sleep(600 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if decision, _, _ := concurrencyLimiter.Acquire(ctx, "192.168.1.1", false); !decision.Allowed {
+			t.Fatalf("Expired leases should free slots left by a crashed instance, got %+v", decision)
+		}
+	}
+
+	_, renewed, _ := concurrencyLimiter.Acquire(ctx, "192.168.1.2", false)
+	for i := 0; i < 3; i++ {
+		time.Sleep(300 * time.Millisecond)
+		if err := concurrencyLimiter.Renew(ctx, renewed); err != nil {
+			t.Fatalf("Renewal %d failed: %v", i+1, err)
+		}
+	}
+	if inFlight, _ := client.ZCard(ctx, "concurrency:ip:192.168.1.2").Result(); inFlight != 1 {
+		t.Fatalf("Expected the renewed lease to outlive its duration, got %d leases", inFlight)
+	}
+	if err := concurrencyLimiter.Release(ctx, renewed); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := concurrencyLimiter.Renew(ctx, renewed); !errors.Is(err, limiter.ErrLeaseExpired) {
+		t.Fatalf("Expected a released lease not to be renewed, got %v", err)
+	}
+}
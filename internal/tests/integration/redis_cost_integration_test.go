@@ -0,0 +1,78 @@
+package integration
+
+import (
+	"context"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/ankardo/Rate-Limiter/internal/infrastructure/persistence"
+)
+
+func TestRedisWeightedRequests(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	client, err := persistence.NewRedisClient(ctx, redisAddr, "")
+	if err != nil {
+		t.Fatalf("Failed to connect to Redis at %v: %v", redisAddr, err)
+	}
+	defer client.Close()
+
+	store := persistence.NewRedisStore(client)
+	config := domain.LimiterConfig{
+		MaxRequests: 10,
+		Window:      time.Minute,
+		Limits:      []domain.LimitRule{{MaxRequests: 10, Window: time.Minute}},
+	}
+	limiters := map[string]domain.WeightedLimiter{
+		domain.StrategyWindow:        limiter.NewRedisRateLimiter(store, config),
+		domain.StrategyTokenBucket:   limiter.NewRedisTokenBucketLimiter(store, config),
+		domain.StrategyGCRA:          limiter.NewRedisGCRALimiter(store, config),
+		domain.StrategySlidingLog:    limiter.NewRedisSlidingLogLimiter(store, config),
+		domain.StrategySlidingWindow: limiter.NewRedisSlidingWindowLimiter(store, config),
+		domain.StrategyStacked:       limiter.NewRedisStackedLimiter(store, config),
+	}
+
+	for strategy, rateLimiter := range limiters {
+		t.Run(strategy, func(t *testing.T) {
+			if err := client.FlushDB(ctx).Err(); err != nil {
+				t.Fatalf("Failed to flush Redis: %v", err)
+			}
+
+			for i := 0; i < 2; i++ {
+				decision, err := rateLimiter.EvaluateCost(ctx, "192.168.1.1", false, 4)
+				if err != nil || !decision.Allowed {
+					t.Fatalf("Request %d costing 4 should be allowed, got %+v (%v)", i+1, decision, err)
+				}
+			}
+
+			decision, err := rateLimiter.EvaluateCost(ctx, "192.168.1.1", false, 4)
+			if err != nil || decision.Allowed || decision.RetryAfter <= 0 {
+				t.Fatalf("Request costing 4 should exceed the remaining budget, got %+v (%v)", decision, err)
+			}
+
+			decision, err = rateLimiter.EvaluateCost(ctx, "192.168.1.1", false, 2)
+			if err != nil || !decision.Allowed || decision.Remaining != 0 {
+				t.Fatalf("Request costing 2 should use the remaining budget, got %+v (%v)", decision, err)
+			}
+
+			for _, cost := range []int64{11, math.MaxInt64} {
+				decision, err = rateLimiter.EvaluateCost(ctx, "10.0.0.9", false, cost)
+				if err != nil || decision.Allowed {
+					t.Fatalf("Request costing %d should be denied without a store error, got %+v (%v)", cost, decision, err)
+				}
+			}
+			decision, err = rateLimiter.EvaluateCost(ctx, "10.0.0.9", false, 10)
+			if err != nil || !decision.Allowed {
+				t.Fatalf("Oversized requests should not consume the budget, got %+v (%v)", decision, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/ankardo/Rate-Limiter/internal/infrastructure/persistence"
+)
+
+func TestRedisGCRALimiterIntegration(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	client, err := persistence.NewRedisClient(ctx, redisAddr, "")
+	if err != nil {
+		t.Fatalf("Failed to connect to Redis at %v: %v", redisAddr, err)
+	}
+	defer client.Close()
+
+	if err := client.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("Failed to flush Redis: %v", err)
+	}
+
+	rateLimiter := limiter.NewRedisGCRALimiter(persistence.NewRedisStore(client), domain.LimiterConfig{
+		MaxRequests:   2,
+		BurstCapacity: 3,
+	})
+
+	for i := 0; i < 3; i++ {
+		allowed, err := rateLimiter.AllowRequest(ctx, "192.168.1.1", false)
+		if err != nil {
+			t.Fatalf("Request %d failed: %v", i+1, err)
+		}
+		if !allowed {
+			t.Fatalf("Request %d should have been allowed within the burst", i+1)
+		}
+	}
+
+	decision, err := rateLimiter.Evaluate(ctx, "192.168.1.1", false)
+	if err != nil {
+		t.Fatalf("Request after burst failed: %v", err)
+	}
+	if decision.Allowed {
+		t.Fatalf("Request after burst should have been denied")
+	}
+	if decision.RetryAfter <= 0 || decision.RetryAfter > 500*time.Millisecond {
+		t.Fatalf("Expected a retry-after within one emission interval, got %v", decision.RetryAfter)
+	}
+
+	keyType, err := client.Type(ctx, "gcra:ip:192.168.1.1").Result()
+	if err != nil || keyType != "string" {
+		t.Fatalf("Expected a single string timestamp per key, got %q (%v)", keyType, err)
+	}
+
+	time.Sleep(decision.RetryAfter + 50*time.Millisecond)
+
+	allowed, err := rateLimiter.AllowRequest(ctx, "192.168.1.1", false)
+	if err != nil {
+		t.Fatalf("Request after retry-after failed: %v", err)
+	}
+	if !allowed {
+		t.Fatalf("Request after retry-after should have been allowed")
+	}
+}
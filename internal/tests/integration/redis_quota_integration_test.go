@@ -0,0 +1,158 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/apikey"
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/app/middleware"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/ankardo/Rate-Limiter/internal/dto"
+	"github.com/ankardo/Rate-Limiter/internal/infrastructure/persistence"
+	"github.com/ankardo/Rate-Limiter/internal/infrastructure/webserver"
+)
+
+func TestRedisQuotaPersistsAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	client, err := persistence.NewRedisClient(ctx, redisAddr, "")
+	if err != nil {
+		t.Fatalf("Failed to connect to Redis at %v: %v", redisAddr, err)
+	}
+	defer client.Close()
+
+	if err := client.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("Failed to flush Redis: %v", err)
+	}
+
+	rules := []domain.QuotaRule{
+		{Period: domain.QuotaDaily, Limit: 3},
+		{Period: domain.QuotaMonthly, Limit: 100},
+	}
+	store := persistence.NewRedisStore(client)
+	quotaLimiter := limiter.NewRedisQuotaLimiter(store, rules, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if decision, err := quotaLimiter.EvaluateQuota(ctx, "abc", 1); err != nil || !decision.Allowed {
+			t.Fatalf("Request %d should be within quota, got %+v (%v)", i+1, decision, err)
+		}
+	}
+
+	restarted := limiter.NewRedisQuotaLimiter(persistence.NewRedisStore(client), rules, time.UTC)
+	if decision, err := restarted.EvaluateQuota(ctx, "abc", 1); err != nil || !decision.Allowed || decision.Remaining != 0 {
+		t.Fatalf("Usage should survive a restart, got %+v (%v)", decision, err)
+	}
+
+	decision, err := restarted.EvaluateQuota(ctx, "abc", 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decision.Allowed || decision.Reason != domain.ReasonQuotaExceeded || decision.Policy != "token-daily" {
+		t.Fatalf("Expected the daily quota to be exhausted, got %+v", decision)
+	}
+
+	usage, err := restarted.Usage(ctx, "abc")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if usage[0].Used != 3 || usage[1].Used != 3 || usage[1].Remaining != 97 {
+		t.Fatalf("Denied requests should not consume quota, got %+v", usage)
+	}
+
+	now := time.Now().UTC()
+	dailyKey := "quota:token:abc:daily:" + now.Format("20060102")
+	ttl, err := client.PTTL(ctx, dailyKey).Result()
+	midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	if err != nil || ttl <= 0 || ttl > time.Until(midnight)+time.Second {
+		t.Fatalf("Expected %s to expire at UTC midnight, got %v (%v)", dailyKey, ttl, err)
+	}
+}
+
+func TestRedisQuotaAdminReadsMiddlewareCounters(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	client, err := persistence.NewRedisClient(ctx, redisAddr, "")
+	if err != nil {
+		t.Fatalf("Failed to connect to Redis at %v: %v", redisAddr, err)
+	}
+	defer client.Close()
+
+	rules := []domain.QuotaRule{{Period: domain.QuotaDaily, Limit: 10}}
+	registry := apikey.NewRegistry([]domain.APIKey{{Hash: apikey.HashKey("registered"), Owner: "acme"}}, nil)
+
+	tests := []struct {
+		name       string
+		registered bool
+		keys       middleware.KeyExtractor
+		token      string
+		storedKey  string
+	}{
+		{
+			name:       "registry",
+			registered: true,
+			keys:       middleware.RegisteredKey(registry, middleware.UnknownAPIKeyReject, nil, middleware.DefaultKeyExtractor(nil)),
+			token:      "registered",
+			storedKey:  apikey.HashKey("registered"),
+		},
+		{
+			name:      "namespaced token",
+			keys:      middleware.DefaultKeyExtractor(nil),
+			token:     "jwt:acme:user-1",
+			storedKey: "raw:jwt:acme:user-1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := client.FlushDB(ctx).Err(); err != nil {
+				t.Fatalf("Failed to flush Redis: %v", err)
+			}
+			quota := limiter.NewRedisQuotaLimiter(persistence.NewRedisStore(client), rules, time.UTC)
+			rateLimiter := limiter.NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 100, TokenMaxRequests: 100, Window: time.Minute})
+			defer rateLimiter.Close()
+			router := webserver.NewRouter(middleware.RateLimiterMiddlewareWithConfig(rateLimiter, middleware.MiddlewareConfig{
+				Quota: quota,
+				Keys:  tt.keys,
+			}), webserver.RouterConfig{Quota: quota, Keys: tt.keys, RegisteredKeys: tt.registered, AdminToken: "secret"})
+
+			for i := 0; i < 3; i++ {
+				req := httptest.NewRequest("GET", "/", nil)
+				req.Header.Set("API_KEY", tt.token)
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, req)
+				if res.Code != http.StatusOK {
+					t.Fatalf("Request %d: expected 200, got %d", i+1, res.Code)
+				}
+			}
+
+			for _, path := range []string{"/admin/quotas/" + url.PathEscape(tt.token), "/admin/quotas?key=" + url.QueryEscape(tt.storedKey)} {
+				req := httptest.NewRequest("GET", path, nil)
+				req.Header.Set("X-Admin-Token", "secret")
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, req)
+
+				var response dto.QuotaResponse
+				if err := json.NewDecoder(res.Body).Decode(&response); err != nil || res.Code != http.StatusOK {
+					t.Fatalf("%s: expected a quota response, got %d (%v)", path, res.Code, err)
+				}
+				if response.Token != tt.storedKey || len(response.Quotas) != 1 || response.Quotas[0].Used != 3 {
+					t.Fatalf("%s: expected the middleware's usage, got %+v", path, response)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/ankardo/Rate-Limiter/internal/infrastructure/persistence"
+)
+
+func TestRedisStackedLimits(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	client, err := persistence.NewRedisClient(ctx, redisAddr, "")
+	if err != nil {
+		t.Fatalf("Failed to connect to Redis at %v: %v", redisAddr, err)
+	}
+	defer client.Close()
+
+	if err := client.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("Failed to flush Redis: %v", err)
+	}
+
+	store := persistence.NewRedisStore(client)
+	rateLimiter := limiter.NewRedisStackedLimiter(store, domain.LimiterConfig{
+		Limits: []domain.LimitRule{
+			{MaxRequests: 2, Window: time.Second},
+			{MaxRequests: 3, Window: time.Minute},
+		},
+	})
+
+	for i := 0; i < 2; i++ {
+		if allowed, err := rateLimiter.AllowRequest(ctx, "192.168.1.1", false); err != nil || !allowed {
+			t.Fatalf("Request %d should be allowed, got %v (%v)", i+1, allowed, err)
+		}
+	}
+
+	decision, err := rateLimiter.Evaluate(ctx, "192.168.1.1", false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decision.Allowed || decision.Policy != "ip-1s" || decision.RetryAfter > time.Second {
+		t.Fatalf("Expected the per-second limit to trip, got %+v", decision)
+	}
+	if count, _ := client.Get(ctx, "limit:ip:192.168.1.1:1:60000").Int64(); count != 2 {
+		t.Fatalf("Denied requests should not be counted, got minute count %d", count)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	decision, err = rateLimiter.Evaluate(ctx, "192.168.1.1", false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !decision.Allowed || decision.Policy != "ip-1m" || decision.Remaining != 0 || len(decision.Limits) != 2 {
+		t.Fatalf("Expected the per-minute limit to be the most restrictive, got %+v", decision)
+	}
+
+	decision, err = rateLimiter.Evaluate(ctx, "192.168.1.1", false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decision.Allowed || decision.Policy != "ip-1m" || decision.RetryAfter < 55*time.Second {
+		t.Fatalf("Expected the per-minute limit to trip, got %+v", decision)
+	}
+
+	ttl, err := client.PTTL(ctx, "limit:ip:192.168.1.1:1:60000").Result()
+	if err != nil || ttl <= 55*time.Second || ttl > time.Minute {
+		t.Fatalf("Expected the minute counter to expire with its window, got %v (%v)", ttl, err)
+	}
+}
+
+func TestRedisStackedLimitsSharingAWindow(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	client, err := persistence.NewRedisClient(ctx, redisAddr, "")
+	if err != nil {
+		t.Fatalf("Failed to connect to Redis at %v: %v", redisAddr, err)
+	}
+	defer client.Close()
+
+	if err := client.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("Failed to flush Redis: %v", err)
+	}
+
+	rateLimiter := limiter.NewRedisStackedLimiter(persistence.NewRedisStore(client), domain.LimiterConfig{
+		Limits: []domain.LimitRule{
+			{MaxRequests: 3, Window: time.Minute},
+			{MaxRequests: 5, Window: time.Minute},
+		},
+	})
+
+	for i := 0; i < 3; i++ {
+		if allowed, err := rateLimiter.AllowRequest(ctx, "192.168.1.1", false); err != nil || !allowed {
+			t.Fatalf("Request %d should be allowed, got %v (%v)", i+1, allowed, err)
+		}
+	}
+	if allowed, _ := rateLimiter.AllowRequest(ctx, "192.168.1.1", false); allowed {
+		t.Fatalf("Expected the tighter rule to trip on request 4")
+	}
+	for i, key := range []string{"limit:ip:192.168.1.1:0:60000", "limit:ip:192.168.1.1:1:60000"} {
+		if count, _ := client.Get(ctx, key).Int64(); count != 3 {
+			t.Fatalf("Rule %d: expected its own counter at 3, got %d", i, count)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/ankardo/Rate-Limiter/internal/infrastructure/persistence"
+)
+
+func TestRedisTokenBucketLimiterIntegration(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	client, err := persistence.NewRedisClient(ctx, redisAddr, "")
+	if err != nil {
+		t.Fatalf("Failed to connect to Redis at %v: %v", redisAddr, err)
+	}
+	defer client.Close()
+
+	if err := client.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("Failed to flush Redis: %v", err)
+	}
+
+	rateLimiter := limiter.NewRedisTokenBucketLimiter(persistence.NewRedisStore(client), domain.LimiterConfig{
+		MaxRequests:   2,
+		BurstCapacity: 5,
+	})
+
+	for i := 0; i < 5; i++ {
+		allowed, err := rateLimiter.AllowRequest(ctx, "192.168.1.1", false)
+		if err != nil {
+			t.Fatalf("Request %d failed: %v", i+1, err)
+		}
+		if !allowed {
+			t.Fatalf("Request %d should have been allowed within the burst capacity", i+1)
+		}
+	}
+
+	allowed, err := rateLimiter.AllowRequest(ctx, "192.168.1.1", false)
+	if err != nil {
+		t.Fatalf("Request after burst failed: %v", err)
+	}
+	if allowed {
+		t.Fatalf("Request after burst should have been denied")
+	}
+
+	ttl, err := client.TTL(ctx, "bucket:ip:192.168.1.1").Result()
+	if err != nil {
+		t.Fatalf("Failed to get bucket TTL: %v", err)
+	}
+	if ttl <= 0 {
+		t.Fatalf("Expected bucket key to have a TTL, got %v", ttl)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	allowed, err = rateLimiter.AllowRequest(ctx, "192.168.1.1", false)
+	if err != nil {
+		t.Fatalf("Request after refill failed: %v", err)
+	}
+	if !allowed {
+		t.Fatalf("Request after refill should have been allowed")
+	}
+}
@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/ankardo/Rate-Limiter/internal/infrastructure/persistence"
+)
+
+func TestRedisConfigurableWindowDrivesExpiry(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	client, err := persistence.NewRedisClient(ctx, redisAddr, "")
+	if err != nil {
+		t.Fatalf("Failed to connect to Redis at %v: %v", redisAddr, err)
+	}
+	defer client.Close()
+
+	if err := client.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("Failed to flush Redis: %v", err)
+	}
+
+	config := domain.LimiterConfig{
+		MaxRequests:      3,
+		TokenMaxRequests: 3,
+		Window:           time.Minute,
+		TokenWindow:      time.Hour,
+	}
+	store := persistence.NewRedisStore(client)
+
+	tests := []struct {
+		name    string
+		limiter domain.DecisionLimiter
+		key     string
+		isToken bool
+		redis   string
+		window  time.Duration
+	}{
+		{name: "window", limiter: limiter.NewRedisRateLimiter(store, config), key: "10.0.0.1", redis: "ip:10.0.0.1", window: time.Minute},
+		{name: "window token", limiter: limiter.NewRedisRateLimiter(store, config), key: "abc", isToken: true, redis: "token:abc", window: time.Hour},
+		{name: "sliding_log", limiter: limiter.NewRedisSlidingLogLimiter(store, config), key: "10.0.0.1", redis: "log:ip:10.0.0.1", window: time.Minute},
+		{name: "sliding_window", limiter: limiter.NewRedisSlidingWindowLimiter(store, config), key: "10.0.0.1", redis: "window:ip:10.0.0.1", window: 2 * time.Minute},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i := 0; i < 3; i++ {
+				if allowed, err := test.limiter.AllowRequest(ctx, test.key, test.isToken); err != nil || !allowed {
+					t.Fatalf("Request %d should be allowed, got %v (%v)", i+1, allowed, err)
+				}
+			}
+			decision, err := test.limiter.Evaluate(ctx, test.key, test.isToken)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if decision.Allowed || decision.RetryAfter < time.Second {
+				t.Fatalf("Expected a denial lasting most of the window, got %+v", decision)
+			}
+
+			ttl, err := client.PTTL(ctx, test.redis).Result()
+			if err != nil {
+				t.Fatalf("Failed to get TTL for %s: %v", test.redis, err)
+			}
+			if ttl <= test.window-5*time.Second || ttl > test.window {
+				t.Fatalf("Expected %s to expire with the %v window, got %v", test.redis, test.window, ttl)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/app/middleware"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/ankardo/Rate-Limiter/internal/infrastructure/webserver"
+)
+
+func TestRouterLimitsEveryPathIntegration(t *testing.T) {
+	memoryLimiter := limiter.NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 2, Window: time.Minute})
+	policies := []middleware.RoutePolicy{{RoutePolicy: domain.RoutePolicy{Name: "/health", Pattern: "/health", Exempt: true}}}
+	router := webserver.NewRouter(middleware.RateLimiterMiddlewareWithConfig(memoryLimiter, middleware.MiddlewareConfig{
+		HeaderStyle: middleware.HeaderStyleBoth,
+		Policies:    policies,
+	}), webserver.RouterConfig{BlockManager: memoryLimiter, AdminToken: "secret"})
+
+	request := func(method, path, token string) int {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("X-Admin-Token", token)
+		}
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	steps := []struct {
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		{method: "GET", path: "/missing", status: http.StatusNotFound},
+		{method: "DELETE", path: "/", status: http.StatusMethodNotAllowed},
+		{method: "GET", path: "/", status: http.StatusTooManyRequests},
+		{method: "GET", path: "/admin/missing", token: "secret", status: http.StatusTooManyRequests},
+		{method: "GET", path: "/admin/metrics", status: http.StatusTooManyRequests},
+		{method: "GET", path: "/admin/blocks", token: "guess", status: http.StatusTooManyRequests},
+		{method: "GET", path: "/health", status: http.StatusOK},
+		{method: "GET", path: "/admin/blocks", token: "secret", status: http.StatusOK},
+	}
+	for i, step := range steps {
+		if got := request(step.method, step.path, step.token); got != step.status {
+			t.Fatalf("Request %d (%s %s): expected %d, got %d", i+1, step.method, step.path, step.status, got)
+		}
+	}
+}
+
+func TestRouterLimitsUnauthenticatedAdminIntegration(t *testing.T) {
+	memoryLimiter := limiter.NewMemoryRateLimiter(domain.LimiterConfig{MaxRequests: 3, Window: time.Minute})
+	router := webserver.NewRouter(middleware.RateLimiterMiddleware(memoryLimiter), webserver.RouterConfig{
+		BlockManager: memoryLimiter,
+		AdminToken:   "secret",
+	})
+
+	codes := []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusTooManyRequests}
+	for i, code := range codes {
+		req := httptest.NewRequest("GET", "/admin/metrics", nil)
+		req.Header.Set("X-Admin-Token", fmt.Sprintf("guess-%d", i))
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if res.Code != code {
+			t.Fatalf("Attempt %d: expected %d, got %d", i+1, code, res.Code)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ankardo/Rate-Limiter/internal/app/limiter"
+	"github.com/ankardo/Rate-Limiter/internal/domain"
+	"github.com/ankardo/Rate-Limiter/internal/infrastructure/persistence"
+)
+
+type conformanceStep struct {
+	at           time.Duration
+	key          string
+	isToken      bool
+	expectStatus []bool
+}
+
+func TestSlidingWindowConformance(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	client, err := persistence.NewRedisClient(ctx, redisAddr, "")
+	if err != nil {
+		t.Fatalf("Failed to connect to Redis at %v: %v", redisAddr, err)
+	}
+	defer client.Close()
+	store := persistence.NewRedisStore(client)
+
+	config := domain.LimiterConfig{
+		MaxRequests:      5,
+		TokenMaxRequests: 8,
+	}
+
+	strategies := []struct {
+		name     string
+		backends map[string]func() domain.Limiter
+		steps    []conformanceStep
+	}{
+		{
+			name: domain.StrategySlidingLog,
+			backends: map[string]func() domain.Limiter{
+				"memory": func() domain.Limiter { return limiter.NewMemoryRateLimiter(config) },
+				"redis":  func() domain.Limiter { return limiter.NewRedisSlidingLogLimiter(store, config) },
+			},
+			steps: []conformanceStep{
+				{at: 50 * time.Millisecond, key: "192.168.1.1", expectStatus: []bool{true, true, true, true, true, false}},
+				{at: 50 * time.Millisecond, key: "192.168.1.1", isToken: true, expectStatus: []bool{true, true, true, true, true, true, true, true, false}},
+				{at: 500 * time.Millisecond, key: "192.168.1.1", expectStatus: []bool{false}},
+				{at: 1200 * time.Millisecond, key: "192.168.1.1", expectStatus: []bool{true, true, true, true, true, false}},
+			},
+		},
+		{
+			name: domain.StrategySlidingWindow,
+			backends: map[string]func() domain.Limiter{
+				"memory": func() domain.Limiter { return limiter.NewMemorySlidingWindowLimiter(config) },
+				"redis":  func() domain.Limiter { return limiter.NewRedisSlidingWindowLimiter(store, config) },
+			},
+			steps: []conformanceStep{
+				{at: 50 * time.Millisecond, key: "192.168.1.1", expectStatus: []bool{true, true, true, true, true, false}},
+				{at: 50 * time.Millisecond, key: "192.168.1.1", isToken: true, expectStatus: []bool{true, true, true, true, true, true, true, true, false}},
+				{at: 1500 * time.Millisecond, key: "192.168.1.1", expectStatus: []bool{true, true, true, false}},
+				{at: 3500 * time.Millisecond, key: "192.168.1.1", expectStatus: []bool{true, true, true, true, true, false}},
+			},
+		},
+	}
+
+	for _, strategy := range strategies {
+		for backend, newLimiter := range strategy.backends {
+			t.Run(strategy.name+"/"+backend, func(t *testing.T) {
+				if err := client.FlushDB(ctx).Err(); err != nil {
+					t.Fatalf("Failed to flush Redis: %v", err)
+				}
+				rateLimiter := newLimiter()
+
+				start := time.Now().Truncate(time.Second).Add(time.Second)
+				for _, step := range strategy.steps {
+					time.Sleep(time.Until(start.Add(step.at)))
+					for i, expected := range step.expectStatus {
+						allowed, err := rateLimiter.AllowRequest(ctx, step.key, step.isToken)
+						if err != nil {
+							t.Fatalf("Step at %v: request %d failed: %v", step.at, i+1, err)
+						}
+						if allowed != expected {
+							t.Fatalf("Step at %v: request %d: expected %v, got %v", step.at, i+1, expected, allowed)
+						}
+					}
+				}
+			})
+		}
+	}
+}